@@ -0,0 +1,273 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// Supported SASL mechanisms
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismScramSHA256 = "SCRAM-SHA-256"
+	SASLMechanismScramSHA512 = "SCRAM-SHA-512"
+	SASLMechanismOAuthBearer = "OAUTHBEARER"
+)
+
+// Secret reference prefixes
+const (
+	SecretEnvPrefix  = "env:"
+	SecretFilePrefix = "file:"
+)
+
+// Authentication messages
+const (
+	AuthUnknownMechanism   = "unknown SASL mechanism %q, expected %s, %s, %s or %s"
+	AuthMissingCredentials = "the SASL mechanism %s requires a username and password"
+	AuthMissingTokenFile   = "the SASL mechanism %s requires a token file"
+	AuthMissingKeyPair     = "a TLS client certificate requires both a certificate and a key file"
+	AuthInvalidCA          = "no valid certificates found inside the TLS CA file %s"
+	AuthUndefinedSecret    = "the environment variable %s referenced by a secret is not defined"
+	AuthEmptyToken         = "the OAUTHBEARER token file %s is empty"
+)
+
+// Authentication represents the TLS and SASL settings used to connect to a secured Kafka cluster
+type Authentication struct {
+	TLS  TLSOptions  `yaml:"tls"`
+	SASL SASLOptions `yaml:"sasl"`
+}
+
+// TLSOptions represents the TLS settings of the Kafka connection.
+// TLS is enabled when enabled is set or when any of the files is defined.
+type TLSOptions struct {
+	Enable             bool   `yaml:"enable"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	ServerName         string `yaml:"server_name"`
+}
+
+// SASLOptions represents the SASL settings of the Kafka connection.
+// The username and password are secrets, see ResolveSecret.
+type SASLOptions struct {
+	Mechanism string `yaml:"mechanism"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	TokenFile string `yaml:"token_file"`
+}
+
+// Override returns a copy of the authentication in which all values defined in the given
+// authentication replace the current values.
+func (auth Authentication) Override(other Authentication) Authentication {
+	override := func(current *string, v string) {
+		if len(v) > 0 {
+			*current = v
+		}
+	}
+
+	auth.TLS.Enable = auth.TLS.Enable || other.TLS.Enable
+	auth.TLS.InsecureSkipVerify = auth.TLS.InsecureSkipVerify || other.TLS.InsecureSkipVerify
+	override(&auth.TLS.CAFile, other.TLS.CAFile)
+	override(&auth.TLS.CertFile, other.TLS.CertFile)
+	override(&auth.TLS.KeyFile, other.TLS.KeyFile)
+	override(&auth.TLS.ServerName, other.TLS.ServerName)
+	override(&auth.SASL.Mechanism, other.SASL.Mechanism)
+	override(&auth.SASL.Username, other.SASL.Username)
+	override(&auth.SASL.Password, other.SASL.Password)
+	override(&auth.SASL.TokenFile, other.SASL.TokenFile)
+
+	return auth
+}
+
+// Configure applies the authentication settings to the given Sarama config
+func (auth Authentication) Configure(config *sarama.Config) error {
+	err := auth.TLS.Configure(config)
+	if err != nil {
+		return err
+	}
+
+	return auth.SASL.Configure(config)
+}
+
+// Configure applies the TLS settings to the given Sarama config
+func (options TLSOptions) Configure(config *sarama.Config) error {
+	if !options.Enable && len(options.CAFile) == 0 && len(options.CertFile) == 0 && len(options.KeyFile) == 0 {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: options.InsecureSkipVerify,
+		ServerName:         options.ServerName,
+	}
+
+	if len(options.CAFile) > 0 {
+		pem, err := ioutil.ReadFile(options.CAFile)
+		if err != nil {
+			return err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf(AuthInvalidCA, options.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(options.CertFile) > 0 || len(options.KeyFile) > 0 {
+		if len(options.CertFile) == 0 || len(options.KeyFile) == 0 {
+			return errors.New(AuthMissingKeyPair)
+		}
+
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = tlsConfig
+
+	return nil
+}
+
+// Configure applies the SASL settings to the given Sarama config.
+// Secrets are resolved before they are applied.
+func (options SASLOptions) Configure(config *sarama.Config) error {
+	mechanism := strings.ToUpper(options.Mechanism)
+
+	switch mechanism {
+	case "":
+		return nil
+	case SASLMechanismPlain, SASLMechanismScramSHA256, SASLMechanismScramSHA512:
+		username, err := ResolveSecret(options.Username)
+		if err != nil {
+			return err
+		}
+
+		password, err := ResolveSecret(options.Password)
+		if err != nil {
+			return err
+		}
+
+		if len(username) == 0 || len(password) == 0 {
+			return fmt.Errorf(AuthMissingCredentials, mechanism)
+		}
+
+		config.Net.SASL.User = username
+		config.Net.SASL.Password = password
+
+		switch mechanism {
+		case SASLMechanismPlain:
+			config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		case SASLMechanismScramSHA256:
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			config.Net.SASL.SCRAMClientGeneratorFunc = SCRAMClientGenerator(scram.SHA256)
+		case SASLMechanismScramSHA512:
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			config.Net.SASL.SCRAMClientGeneratorFunc = SCRAMClientGenerator(scram.SHA512)
+		}
+	case SASLMechanismOAuthBearer:
+		if len(options.TokenFile) == 0 {
+			return fmt.Errorf(AuthMissingTokenFile, mechanism)
+		}
+
+		config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		config.Net.SASL.TokenProvider = TokenFile(options.TokenFile)
+	default:
+		return fmt.Errorf(AuthUnknownMechanism, options.Mechanism, SASLMechanismPlain, SASLMechanismScramSHA256, SASLMechanismScramSHA512, SASLMechanismOAuthBearer)
+	}
+
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Handshake = true
+
+	return nil
+}
+
+// SCRAMClient represents a SCRAM conversation with a Kafka broker using the given hash function
+type SCRAMClient struct {
+	hash         scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+// SCRAMClientGenerator returns a generator of SCRAM clients using the given hash function
+func SCRAMClientGenerator(hash scram.HashGeneratorFcn) func() sarama.SCRAMClient {
+	return func() sarama.SCRAMClient {
+		return &SCRAMClient{hash: hash}
+	}
+}
+
+// Begin starts a new SCRAM conversation for the given credentials
+func (client *SCRAMClient) Begin(username, password, authzID string) error {
+	scramClient, err := client.hash.NewClient(username, password, authzID)
+	if err != nil {
+		return err
+	}
+
+	client.conversation = scramClient.NewConversation()
+	return nil
+}
+
+// Step processes the given server challenge and returns the response of the client
+func (client *SCRAMClient) Step(challenge string) (string, error) {
+	return client.conversation.Step(challenge)
+}
+
+// Done returns true if the SCRAM conversation completed
+func (client *SCRAMClient) Done() bool {
+	return client.conversation.Done()
+}
+
+// TokenFile represents a file containing a OAUTHBEARER access token.
+// The file is read every time a token is requested which allows the token to be refreshed by a external process.
+type TokenFile string
+
+// Token reads the access token from the token file
+func (path TokenFile) Token() (*sarama.AccessToken, error) {
+	token, err := ioutil.ReadFile(string(path))
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := strings.TrimSpace(string(token))
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf(AuthEmptyToken, path)
+	}
+
+	return &sarama.AccessToken{Token: trimmed}, nil
+}
+
+// ResolveSecret resolves the given secret reference. Secrets prefixed with env: are read from the
+// named environment variable, secrets prefixed with file: are read from the given file.
+// All other values are returned as is.
+func ResolveSecret(secret string) (string, error) {
+	switch {
+	case strings.HasPrefix(secret, SecretEnvPrefix):
+		name := strings.TrimPrefix(secret, SecretEnvPrefix)
+		resolved, has := os.LookupEnv(name)
+		if !has {
+			return "", fmt.Errorf(AuthUndefinedSecret, name)
+		}
+
+		return resolved, nil
+	case strings.HasPrefix(secret, SecretFilePrefix):
+		resolved, err := ioutil.ReadFile(strings.TrimPrefix(secret, SecretFilePrefix))
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(resolved), "\r\n"), nil
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+)
+
+func TestSASLConfigure(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   SASLOptions
+		mechanism sarama.SASLMechanism
+		scram     bool
+		err       bool
+	}{
+		{name: "disabled", options: SASLOptions{}},
+		{name: "plain", options: SASLOptions{Mechanism: "plain", Username: "kafkat", Password: "secret"}, mechanism: sarama.SASLTypePlaintext},
+		{name: "scram sha 256", options: SASLOptions{Mechanism: "SCRAM-SHA-256", Username: "kafkat", Password: "secret"}, mechanism: sarama.SASLTypeSCRAMSHA256, scram: true},
+		{name: "scram sha 512", options: SASLOptions{Mechanism: "scram-sha-512", Username: "kafkat", Password: "secret"}, mechanism: sarama.SASLTypeSCRAMSHA512, scram: true},
+		{name: "scram without password", options: SASLOptions{Mechanism: "SCRAM-SHA-256", Username: "kafkat"}, err: true},
+		{name: "oauthbearer without token file", options: SASLOptions{Mechanism: "OAUTHBEARER"}, err: true},
+		{name: "unknown", options: SASLOptions{Mechanism: "GSSAPI"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := sarama.NewConfig()
+			err := test.options.Configure(config)
+			if test.err {
+				if err == nil {
+					t.Error("expected a error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(test.mechanism) > 0 && config.Net.SASL.Mechanism != test.mechanism {
+				t.Errorf("unexpected mechanism %s, expected %s", config.Net.SASL.Mechanism, test.mechanism)
+			}
+
+			if test.scram != (config.Net.SASL.SCRAMClientGeneratorFunc != nil) {
+				t.Errorf("unexpected SCRAM client generator")
+			}
+
+			err = config.Validate()
+			if err != nil {
+				t.Errorf("invalid config: %s", err)
+			}
+		})
+	}
+}
+
+func TestSCRAMClient(t *testing.T) {
+	tests := []struct {
+		name string
+		hash scram.HashGeneratorFcn
+	}{
+		{name: "SHA-256", hash: scram.SHA256},
+		{name: "SHA-512", hash: scram.SHA512},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			credentials, err := test.hash.NewClient("kafkat", "secret", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := credentials.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+			server, err := test.hash.NewServer(func(string) (scram.StoredCredentials, error) {
+				return stored, nil
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := SCRAMClientGenerator(test.hash)()
+			err = client.Begin("kafkat", "secret", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conversation := server.NewConversation()
+			challenge := ""
+
+			for !client.Done() {
+				response, err := client.Step(challenge)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if client.Done() {
+					break
+				}
+
+				challenge, err = conversation.Step(response)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !conversation.Valid() {
+				t.Error("the server did not authenticate the client")
+			}
+		})
+	}
+}
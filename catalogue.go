@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// Topic configuration property types
+const (
+	ConfigTypeBoolean = "boolean"
+	ConfigTypeString  = "string"
+	ConfigTypeInt     = "int"
+	ConfigTypeLong    = "long"
+	ConfigTypeDouble  = "double"
+	ConfigTypeList    = "list"
+)
+
+// Catalogue validation messages
+const (
+	CatalogueUnknownProperty = "unknown topic configuration property %s for Kafka %s"
+	CatalogueUnsupported     = "the topic configuration property %s requires Kafka %s or higher, configured version is %s"
+	CatalogueInvalidType     = "the value %q of %s is not a valid %s"
+	CatalogueOutOfRange      = "the value %q of %s should be between %s and %s"
+	CatalogueInvalidOption   = "the value %q of %s should be one of: %s"
+	CatalogueOptionSince     = "the value %q of %s requires Kafka %s or higher, configured version is %s"
+	CatalogueInsyncReplicas  = "%s is %s but the topic %s has a replication factor of %d"
+)
+
+// Topic configuration property names referenced by cross property rules
+const (
+	ConfigMinInsyncReplicas = "min.insync.replicas"
+)
+
+// Versions introducing topic configuration properties
+var (
+	V2_3_0_0 = mustParseKafkaVersion("2.3.0")
+)
+
+// ConfigRange represents the inclusive range of a numeric configuration property
+type ConfigRange struct {
+	Min float64
+	Max float64
+}
+
+// ConfigDefinition represents a known Kafka topic configuration property
+type ConfigDefinition struct {
+	Name    string
+	Type    string
+	Range   *ConfigRange
+	Options []string
+	// OptionsSince holds the Kafka version introducing options that were added after the property itself
+	OptionsSince map[string]sarama.KafkaVersion
+	Since        sarama.KafkaVersion
+	// Unit is the unit of numeric properties that accept human readable values
+	Unit string
+}
+
+// atLeast returns a range accepting all values greater or equal to the given minimum
+func atLeast(min float64) *ConfigRange {
+	return &ConfigRange{Min: min, Max: math.Inf(1)}
+}
+
+// between returns a range accepting all values between the given minimum and maximum
+func between(min, max float64) *ConfigRange {
+	return &ConfigRange{Min: min, Max: max}
+}
+
+// Catalogue holds the known Kafka topic configuration properties by name
+var Catalogue = NewCatalogue(
+	ConfigDefinition{Name: "cleanup.policy", Type: ConfigTypeList, Options: []string{"delete", "compact"}, Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "compression.type", Type: ConfigTypeString, Options: []string{"uncompressed", "zstd", "snappy", "lz4", "gzip", "producer"}, OptionsSince: map[string]sarama.KafkaVersion{"zstd": sarama.V2_1_0_0}, Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "delete.retention.ms", Type: ConfigTypeLong, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "file.delete.delay.ms", Type: ConfigTypeLong, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "flush.messages", Type: ConfigTypeLong, Range: atLeast(0), Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "flush.ms", Type: ConfigTypeLong, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "follower.replication.throttled.replicas", Type: ConfigTypeList, Since: sarama.V0_10_1_0},
+	ConfigDefinition{Name: "index.interval.bytes", Type: ConfigTypeInt, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitBytes},
+	ConfigDefinition{Name: "leader.replication.throttled.replicas", Type: ConfigTypeList, Since: sarama.V0_10_1_0},
+	ConfigDefinition{Name: "max.compaction.lag.ms", Type: ConfigTypeLong, Range: atLeast(1), Since: V2_3_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "max.message.bytes", Type: ConfigTypeInt, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitBytes},
+	ConfigDefinition{Name: "message.downconversion.enable", Type: ConfigTypeBoolean, Since: sarama.V2_0_0_0},
+	ConfigDefinition{Name: "message.format.version", Type: ConfigTypeString, Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "message.timestamp.difference.max.ms", Type: ConfigTypeLong, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "message.timestamp.type", Type: ConfigTypeString, Options: []string{"CreateTime", "LogAppendTime"}, Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "min.cleanable.dirty.ratio", Type: ConfigTypeDouble, Range: between(0, 1), Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "min.compaction.lag.ms", Type: ConfigTypeLong, Range: atLeast(0), Since: sarama.V0_10_1_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: ConfigMinInsyncReplicas, Type: ConfigTypeInt, Range: atLeast(1), Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "preallocate", Type: ConfigTypeBoolean, Since: sarama.V0_10_0_0},
+	ConfigDefinition{Name: "retention.bytes", Type: ConfigTypeLong, Range: atLeast(-1), Since: sarama.V0_10_0_0, Unit: UnitBytes},
+	ConfigDefinition{Name: "retention.ms", Type: ConfigTypeLong, Range: atLeast(-1), Since: sarama.V0_10_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "segment.bytes", Type: ConfigTypeInt, Range: atLeast(14), Since: sarama.V0_10_0_0, Unit: UnitBytes},
+	ConfigDefinition{Name: "segment.index.bytes", Type: ConfigTypeInt, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitBytes},
+	ConfigDefinition{Name: "segment.jitter.ms", Type: ConfigTypeLong, Range: atLeast(0), Since: sarama.V0_10_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "segment.ms", Type: ConfigTypeLong, Range: atLeast(1), Since: sarama.V0_10_0_0, Unit: UnitMilliseconds},
+	ConfigDefinition{Name: "unclean.leader.election.enable", Type: ConfigTypeBoolean, Since: sarama.V0_10_0_0},
+)
+
+// NewCatalogue constructs a new catalogue of the given configuration definitions
+func NewCatalogue(definitions ...ConfigDefinition) map[string]ConfigDefinition {
+	catalogue := make(map[string]ConfigDefinition, len(definitions))
+	for _, definition := range definitions {
+		catalogue[definition.Name] = definition
+	}
+
+	return catalogue
+}
+
+// Check validates the given value against the configuration definition for the given Kafka version.
+// A error message is returned if the value is invalid.
+func (definition ConfigDefinition) Check(raw string, version sarama.KafkaVersion) error {
+	if !version.IsAtLeast(definition.Since) {
+		return fmt.Errorf(CatalogueUnsupported, definition.Name, definition.Since, version)
+	}
+
+	var number float64
+	var err error
+
+	switch definition.Type {
+	case ConfigTypeBoolean:
+		_, err = strconv.ParseBool(strings.ToLower(raw))
+	case ConfigTypeInt:
+		var parsed int64
+		parsed, err = strconv.ParseInt(raw, 10, 32)
+		number = float64(parsed)
+	case ConfigTypeLong:
+		var parsed int64
+		parsed, err = strconv.ParseInt(raw, 10, 64)
+		number = float64(parsed)
+	case ConfigTypeDouble:
+		number, err = strconv.ParseFloat(raw, 64)
+	}
+
+	if err != nil {
+		return fmt.Errorf(CatalogueInvalidType, raw, definition.Name, definition.Type)
+	}
+
+	if definition.Range != nil && (number < definition.Range.Min || number > definition.Range.Max) {
+		max := strconv.FormatFloat(definition.Range.Max, 'f', -1, 64)
+		if math.IsInf(definition.Range.Max, 1) {
+			max = "infinity"
+		}
+
+		return fmt.Errorf(CatalogueOutOfRange, raw, definition.Name, strconv.FormatFloat(definition.Range.Min, 'f', -1, 64), max)
+	}
+
+	options := definition.Options
+	if len(options) == 0 {
+		return nil
+	}
+
+	values := []string{raw}
+	if definition.Type == ConfigTypeList {
+		values = strings.Split(raw, ",")
+	}
+
+values:
+	for _, v := range values {
+		for _, option := range options {
+			if strings.TrimSpace(v) != option {
+				continue
+			}
+
+			since, ok := definition.OptionsSince[option]
+			if ok && !version.IsAtLeast(since) {
+				return fmt.Errorf(CatalogueOptionSince, raw, definition.Name, since, version)
+			}
+
+			continue values
+		}
+
+		return fmt.Errorf(CatalogueInvalidOption, raw, definition.Name, strings.Join(options, ", "))
+	}
+
+	return nil
+}
+
+// Lint validates the configuration properties of the given entries against the catalogue of known
+// topic configuration properties for the given Kafka version. No connection to the Kafka cluster
+// is required. All found problems are returned, nil is returned when all entries are valid.
+func Lint(entries []Entry, version sarama.KafkaVersion) ValidationErrors {
+	errs := ValidationErrors{}
+
+	for _, entry := range entries {
+		for _, key := range sortedConfigKeys(entry.Config) {
+			v := entry.Config[key]
+			if v == nil {
+				continue
+			}
+
+			err := lintProperty(key, *v, version)
+			if err == nil {
+				continue
+			}
+
+			errs = append(errs, ValidationError{
+				Path:     entry.Path,
+				Document: entry.Document,
+				Line:     entry.LineOf(EntryKeyConfig + "." + key),
+				Message:  err.Error(),
+				Topic:    entry.Topic[EntryKeyTopicName],
+			})
+		}
+	}
+
+	// Cross property rules are validated against the merged topic declarations
+	for _, entry := range Merge(entries) {
+		replication, err := strconv.ParseInt(entry.Topic[EntryKeyTopicReplicationSize], 0, 16)
+		if err != nil || replication <= 0 {
+			continue
+		}
+
+		insync, err := strconv.ParseInt(value(entry.Config[ConfigMinInsyncReplicas]), 10, 32)
+		if err != nil || insync <= replication {
+			continue
+		}
+
+		errs = append(errs, ValidationError{
+			Path:     entry.Path,
+			Document: entry.Document,
+			Line:     entry.LineOf(EntryKeyConfig + "." + ConfigMinInsyncReplicas),
+			Message:  fmt.Sprintf(CatalogueInsyncReplicas, ConfigMinInsyncReplicas, value(entry.Config[ConfigMinInsyncReplicas]), entry.Topic[EntryKeyTopicName], replication),
+			Topic:    entry.Topic[EntryKeyTopicName],
+		})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// lintProperty validates a single configuration property against the catalogue
+func lintProperty(key, raw string, version sarama.KafkaVersion) error {
+	definition, has := Catalogue[key]
+	if !has {
+		return fmt.Errorf(CatalogueUnknownProperty, key, version)
+	}
+
+	return definition.Check(raw, version)
+}
+
+// mustParseKafkaVersion parses the given Kafka version and panics if the version is invalid
+func mustParseKafkaVersion(version string) sarama.KafkaVersion {
+	v, err := sarama.ParseKafkaVersion(version)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
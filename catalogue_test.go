@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestConfigDefinitionCheck(t *testing.T) {
+	tests := []struct {
+		key     string
+		raw     string
+		version sarama.KafkaVersion
+		want    string
+	}{
+		{key: "retention.ms", raw: "604800000", version: sarama.V2_0_0_0},
+		{key: "retention.ms", raw: "-1", version: sarama.V2_0_0_0},
+		{key: "retention.ms", raw: "-2", version: sarama.V2_0_0_0, want: `the value "-2" of retention.ms should be between -1 and infinity`},
+		{key: "retention.ms", raw: "7d", version: sarama.V2_0_0_0, want: `the value "7d" of retention.ms is not a valid long`},
+		{key: "segment.bytes", raw: "14", version: sarama.V2_0_0_0},
+		{key: "segment.bytes", raw: "13", version: sarama.V2_0_0_0, want: `the value "13" of segment.bytes should be between 14 and infinity`},
+		{key: "segment.bytes", raw: "4294967296", version: sarama.V2_0_0_0, want: `the value "4294967296" of segment.bytes is not a valid int`},
+		{key: "min.cleanable.dirty.ratio", raw: "0.5", version: sarama.V2_0_0_0},
+		{key: "min.cleanable.dirty.ratio", raw: "1.5", version: sarama.V2_0_0_0, want: `the value "1.5" of min.cleanable.dirty.ratio should be between 0 and 1`},
+		{key: "preallocate", raw: "TRUE", version: sarama.V2_0_0_0},
+		{key: "preallocate", raw: "yes", version: sarama.V2_0_0_0, want: `the value "yes" of preallocate is not a valid boolean`},
+		{key: "cleanup.policy", raw: "compact,delete", version: sarama.V2_0_0_0},
+		{key: "cleanup.policy", raw: "compact, delete", version: sarama.V2_0_0_0},
+		{key: "cleanup.policy", raw: "compact,archive", version: sarama.V2_0_0_0, want: `the value "compact,archive" of cleanup.policy should be one of: delete, compact`},
+		{key: "compression.type", raw: "lz4", version: sarama.V2_0_0_0},
+		{key: "compression.type", raw: "zstd", version: sarama.V2_0_0_0, want: `the value "zstd" of compression.type requires Kafka 2.1.0 or higher, configured version is 2.0.0`},
+		{key: "compression.type", raw: "zstd", version: sarama.V2_1_0_0},
+		{key: "compression.type", raw: "brotli", version: sarama.V2_1_0_0, want: `the value "brotli" of compression.type should be one of: uncompressed, zstd, snappy, lz4, gzip, producer`},
+		{key: "max.compaction.lag.ms", raw: "1000", version: sarama.V2_1_0_0, want: "the topic configuration property max.compaction.lag.ms requires Kafka 2.3.0 or higher, configured version is 2.1.0"},
+		{key: "max.compaction.lag.ms", raw: "1000", version: V2_3_0_0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key+"="+test.raw+"@"+test.version.String(), func(t *testing.T) {
+			err := Catalogue[test.key].Check(test.raw, test.version)
+			if test.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected the error %q", test.want)
+			}
+
+			if err.Error() != test.want {
+				t.Errorf("unexpected error %q, expected %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    []string
+	}{
+		{
+			name: "valid",
+			entries: []Entry{
+				{Topic: map[string]string{"name": "orders", "replication": "3"}, Config: map[string]*string{"min.insync.replicas": ptr("2"), "cleanup.policy": ptr("compact,delete")}},
+			},
+		},
+		{
+			name: "invalid properties",
+			entries: []Entry{
+				{Topic: map[string]string{"name": "orders"}, Config: map[string]*string{"retention.ms": ptr("-2"), "unknown.property": ptr("1")}},
+			},
+			want: []string{
+				`the value "-2" of retention.ms should be between -1 and infinity`,
+				"unknown topic configuration property unknown.property for Kafka 2.1.0",
+			},
+		},
+		{
+			name: "insync replicas exceed replication",
+			entries: []Entry{
+				{Topic: map[string]string{"name": "orders", "replication": "2"}, Config: map[string]*string{"min.insync.replicas": ptr("3")}},
+			},
+			want: []string{"min.insync.replicas is 3 but the topic orders has a replication factor of 2"},
+		},
+		{
+			name: "insync replicas equal replication",
+			entries: []Entry{
+				{Topic: map[string]string{"name": "orders", "replication": "3"}, Config: map[string]*string{"min.insync.replicas": ptr("3")}},
+			},
+		},
+		{
+			name: "insync replicas merged with overlay",
+			entries: []Entry{
+				{Topic: map[string]string{"name": "orders", "replication": "3"}, Config: map[string]*string{"min.insync.replicas": ptr("3")}},
+				{Topic: map[string]string{"name": "orders", "replication": "1"}, Overlay: true},
+			},
+			want: []string{"min.insync.replicas is 3 but the topic orders has a replication factor of 1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := Lint(test.entries, sarama.V2_1_0_0)
+			if len(errs) != len(test.want) {
+				t.Fatalf("unexpected errors %q, expected %q", errs, test.want)
+			}
+
+			for index, err := range errs {
+				if err.Message != test.want[index] {
+					t.Errorf("unexpected error %q, expected %q", err.Message, test.want[index])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// ClusterEnv is the environment variable selecting the cluster profile when no cluster is defined
+const ClusterEnv = "KAFKAT_CLUSTER"
+
+// DefaultKafkaVersion is the Kafka version used when no version is defined
+const DefaultKafkaVersion = "1.1.0"
+
+// DefaultBatchSize is the maximum number of topics send inside a single admin request when no batch size is defined
+const DefaultBatchSize = 100
+
+// Cluster messages
+const (
+	ClusterUnknown   = "unknown cluster profile %q, available profiles: %v"
+	ClusterNoBrokers = "no Kafka brokers defined, use -brokers or select a cluster profile with -cluster or " + ClusterEnv
+)
+
+// Cluster represents the connection settings of a Kafka cluster.
+// Named cluster profiles are defined inside the project file.
+type Cluster struct {
+	Name         string         `yaml:"-"`
+	Brokers      []string       `yaml:"brokers"`
+	KafkaVersion string         `yaml:"kafka_version"`
+	ClientID     string         `yaml:"client_id"`
+	Environment  string         `yaml:"environment"`
+	DialTimeout  time.Duration  `yaml:"dial_timeout"`
+	AdminTimeout time.Duration  `yaml:"admin_timeout"`
+	BatchSize    int            `yaml:"batch_size"`
+	Auth         Authentication `yaml:"auth"`
+}
+
+// String returns the cluster name followed by its brokers
+func (cluster Cluster) String() string {
+	brokers := strings.Join(cluster.Brokers, ",")
+	if len(cluster.Name) == 0 {
+		return brokers
+	}
+
+	return fmt.Sprintf("%s (%s)", cluster.Name, brokers)
+}
+
+// Version parses the Kafka version of the cluster, the default version is returned when no version is defined
+func (cluster Cluster) Version() (sarama.KafkaVersion, error) {
+	if len(cluster.KafkaVersion) == 0 {
+		return sarama.ParseKafkaVersion(DefaultKafkaVersion)
+	}
+
+	return sarama.ParseKafkaVersion(cluster.KafkaVersion)
+}
+
+// Configure applies the cluster connection settings to the given Sarama config
+func (cluster Cluster) Configure(config *sarama.Config) error {
+	if len(cluster.Brokers) == 0 {
+		return errors.New(ClusterNoBrokers)
+	}
+
+	version, err := cluster.Version()
+	if err != nil {
+		return err
+	}
+
+	config.Version = version
+
+	if len(cluster.ClientID) > 0 {
+		config.ClientID = cluster.ClientID
+	}
+
+	if cluster.DialTimeout > 0 {
+		config.Net.DialTimeout = cluster.DialTimeout
+	}
+
+	if cluster.AdminTimeout > 0 {
+		config.Admin.Timeout = cluster.AdminTimeout
+	}
+
+	return cluster.Auth.Configure(config)
+}
+
+// Cluster returns the cluster profile with the given name.
+// A empty cluster is returned when no name is given.
+func (project *Project) Cluster(name string) (Cluster, error) {
+	if len(name) == 0 {
+		return Cluster{Auth: project.Auth}, nil
+	}
+
+	cluster, has := project.Clusters[name]
+	if !has {
+		available := make([]string, 0, len(project.Clusters))
+		for name := range project.Clusters {
+			available = append(available, name)
+		}
+
+		sort.Strings(available)
+		return Cluster{}, fmt.Errorf(ClusterUnknown, name, available)
+	}
+
+	cluster.Name = name
+	cluster.Auth = project.Auth.Override(cluster.Auth)
+
+	return cluster, nil
+}
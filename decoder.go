@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Decoding messages
+const (
+	DecodeUnexpectedSection = "unexpected %s section type %T, expected a map"
+	DecodeUnexpectedValue   = "unexpected value type %T for the key %s"
+	DecodeDocumentFailed    = "document %d: %s"
+)
+
+// Decoder decodes all topic configuration entries from the given reader.
+// Decoded entries have their document index set.
+type Decoder func(reader io.Reader) ([]Entry, error)
+
+// Decoders holds the available topic configuration decoders by mime type.
+// File extensions are resolved to mime types through the mime package.
+var Decoders = map[string]Decoder{
+	TypeYAML: DecodeYAML,
+	TypeJSON: DecodeJSON,
+	TypeTOML: DecodeTOML,
+	TypeHCL:  DecodeHCL,
+}
+
+// DecodeYAML decodes all YAML documents inside the given reader as topic configuration entries
+func DecodeYAML(reader io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+	dec := yaml.NewDecoder(reader)
+
+	for document := 1; ; document++ {
+		node := yaml.Node{}
+		err := dec.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return entries, fmt.Errorf(DecodeDocumentFailed, document, err)
+		}
+
+		entry := Entry{
+			Document: document,
+		}
+
+		err = node.Decode(&entry)
+		if err != nil {
+			return entries, fmt.Errorf(DecodeDocumentFailed, document, err)
+		}
+
+		entry.Line, entry.Sections, entry.Positions = positions(&node)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// positions returns the start line of the given YAML document, the lines of all root keys
+// and the lines of all keys defined inside root level mappings and environment blocks.
+func positions(node *yaml.Node) (int, map[string]int, map[string]int) {
+	sections := map[string]int{}
+	keys := map[string]int{}
+	line := node.Line
+
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+		line = node.Line
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return line, sections, keys
+	}
+
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		key, section := node.Content[index], node.Content[index+1]
+		sections[key.Value] = key.Line
+
+		if section.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for child := 0; child+1 < len(section.Content); child += 2 {
+			name, block := section.Content[child], section.Content[child+1]
+			keys[key.Value+"."+name.Value] = name.Line
+
+			if key.Value != EntryKeyEnvironments || block.Kind != yaml.MappingNode {
+				continue
+			}
+
+			for grandchild := 0; grandchild+1 < len(block.Content); grandchild += 2 {
+				keys[key.Value+"."+name.Value+"."+block.Content[grandchild].Value] = block.Content[grandchild].Line
+			}
+		}
+	}
+
+	return line, sections, keys
+}
+
+// DecodeJSON decodes the given JSON stream as topic configuration entries.
+// Every JSON object inside the stream represents a single entry,
+// a JSON array is decoded as a sequence of entries.
+func DecodeJSON(reader io.Reader) ([]Entry, error) {
+	bb, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	dec := json.NewDecoder(bytes.NewReader(bb))
+	dec.UseNumber()
+
+	decode := func(offset int64) error {
+		var document interface{}
+		err := dec.Decode(&document)
+		if err != nil {
+			return err
+		}
+
+		entry, err := NewEntry(document)
+		if err != nil {
+			return fmt.Errorf(DecodeDocumentFailed, len(entries)+1, err)
+		}
+
+		entry.Document = len(entries) + 1
+		entry.Line = lineAt(bb, offset)
+		entries = append(entries, entry)
+		return nil
+	}
+
+	for {
+		offset := skipSeparators(bb, dec.InputOffset())
+		if offset >= int64(len(bb)) {
+			break
+		}
+
+		if bb[offset] != '[' {
+			err := decode(offset)
+			if err != nil {
+				return entries, err
+			}
+
+			continue
+		}
+
+		// Consume the opening bracket of the array
+		_, err := dec.Token()
+		if err != nil {
+			return entries, err
+		}
+
+		for dec.More() {
+			err := decode(skipSeparators(bb, dec.InputOffset()))
+			if err != nil {
+				return entries, err
+			}
+		}
+
+		// Consume the closing bracket of the array
+		_, err = dec.Token()
+		if err != nil {
+			return entries, err
+		}
+	}
+
+	return entries, nil
+}
+
+// skipSeparators returns the offset of the first character after the given offset
+// that is not a whitespace or value separator.
+func skipSeparators(bb []byte, offset int64) int64 {
+	for offset < int64(len(bb)) {
+		switch bb[offset] {
+		case ' ', '\t', '\r', '\n', ',':
+			offset++
+			continue
+		}
+
+		break
+	}
+
+	return offset
+}
+
+// lineAt returns the line number of the given byte offset, starting at 1
+func lineAt(bb []byte, offset int64) int {
+	return bytes.Count(bb[:offset], []byte("\n")) + 1
+}
+
+// DecodeTOML decodes the given TOML document as a single topic configuration entry.
+// The positions of keys are unknown for TOML documents.
+func DecodeTOML(reader io.Reader) ([]Entry, error) {
+	document := map[string]interface{}{}
+	_, err := toml.DecodeReader(reader, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := NewEntry(document)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Document = 1
+	entry.Line = 1
+	return []Entry{entry}, nil
+}
+
+// DecodeHCL decodes the given HCL document as a single topic configuration entry
+func DecodeHCL(reader io.Reader) ([]Entry, error) {
+	bb, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := hcl.ParseBytes(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	err = hcl.DecodeObject(&document, file)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := NewEntry(document)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Document = 1
+	entry.Line = 1
+
+	root, is := file.Node.(*ast.ObjectList)
+	if !is {
+		return []Entry{entry}, nil
+	}
+
+	for _, item := range root.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+
+		key := item.Keys[0].Token.Value().(string)
+		entry.Sections[key] = item.Pos().Line
+
+		section, is := item.Val.(*ast.ObjectType)
+		if !is {
+			continue
+		}
+
+		for _, child := range section.List.Items {
+			if len(child.Keys) == 0 {
+				continue
+			}
+
+			entry.Positions[key+"."+child.Keys[0].Token.Value().(string)] = child.Pos().Line
+		}
+	}
+
+	return []Entry{entry}, nil
+}
+
+// NewEntry constructs a new topic configuration entry from the given generic document.
+// Scalar values inside the topic and config sections are converted to their string representation.
+func NewEntry(document interface{}) (Entry, error) {
+	entry := Entry{
+		Sections:  map[string]int{},
+		Positions: map[string]int{},
+	}
+
+	root, err := section("document", document)
+	if err != nil {
+		return entry, err
+	}
+
+	for key := range root {
+		entry.Sections[key] = 0
+	}
+
+	entry.Topic, entry.Config, err = values(root)
+	if err != nil {
+		return entry, err
+	}
+
+	overlay, err := scalar(EntryKeyOverlay, root[EntryKeyOverlay])
+	if err != nil {
+		return entry, err
+	}
+
+	if overlay != nil {
+		entry.Overlay, err = strconv.ParseBool(*overlay)
+		if err != nil {
+			return entry, fmt.Errorf(DecodeUnexpectedValue, root[EntryKeyOverlay], EntryKeyOverlay)
+		}
+	}
+
+	environment, err := scalar(EntryKeyEnvironment, root[EntryKeyEnvironment])
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Environment = value(environment)
+
+	profile, err := scalar(EntryKeyProfile, root[EntryKeyProfile])
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Profile = value(profile)
+
+	environments, err := section(EntryKeyEnvironments, root[EntryKeyEnvironments])
+	if err != nil {
+		return entry, err
+	}
+
+	if environments != nil {
+		entry.Environments = make(map[string]EnvironmentEntry, len(environments))
+	}
+
+	for name, v := range environments {
+		document, err := section(EntryKeyEnvironments+"."+name, v)
+		if err != nil {
+			return entry, err
+		}
+
+		topic, config, err := values(document)
+		if err != nil {
+			return entry, err
+		}
+
+		keys := make([]string, 0, len(document))
+		for key := range document {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		entry.Environments[name] = EnvironmentEntry{
+			Topic:  topic,
+			Config: config,
+			Keys:   keys,
+		}
+	}
+
+	return entry, nil
+}
+
+// values decodes the topic and config sections of the given generic document
+func values(document map[string]interface{}) (map[string]string, map[string]*string, error) {
+	topic, err := section(EntryKeyTopic, document[EntryKeyTopic])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config, err := section(EntryKeyConfig, document[EntryKeyConfig])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var topicValues map[string]string
+	var configValues map[string]*string
+
+	if topic != nil {
+		topicValues = make(map[string]string, len(topic))
+	}
+
+	for key, v := range topic {
+		result, err := scalar(key, v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		topicValues[key] = value(result)
+	}
+
+	if config != nil {
+		configValues = make(map[string]*string, len(config))
+	}
+
+	for key, v := range config {
+		result, err := scalar(key, v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		configValues[key] = result
+	}
+
+	return topicValues, configValues, nil
+}
+
+// section returns the given value as a map. HCL blocks are decoded as a list of maps
+// which are merged into a single map.
+func section(name string, value interface{}) (map[string]interface{}, error) {
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return typed, nil
+	case []map[string]interface{}:
+		result := map[string]interface{}{}
+		for _, block := range typed {
+			for key, v := range block {
+				result[key] = v
+			}
+		}
+
+		return result, nil
+	default:
+		return nil, fmt.Errorf(DecodeUnexpectedSection, name, value)
+	}
+}
+
+// scalar returns the string representation of the given scalar value.
+// A nil pointer is returned for null values.
+func scalar(key string, value interface{}) (*string, error) {
+	var result string
+
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		result = typed
+	case json.Number:
+		result = typed.String()
+	case bool:
+		result = strconv.FormatBool(typed)
+	case int:
+		result = strconv.FormatInt(int64(typed), 10)
+	case int64:
+		result = strconv.FormatInt(typed, 10)
+	case float64:
+		result = strconv.FormatFloat(typed, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf(DecodeUnexpectedValue, value, key)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		topics []string
+		lines  []int
+	}{
+		{
+			name:   "single object",
+			source: `{"topic": {"name": "orders", "partitions": 3}}`,
+			topics: []string{"orders"},
+			lines:  []int{1},
+		},
+		{
+			name:   "array",
+			source: "[\n  {\"topic\": {\"name\": \"orders\"}},\n  {\"topic\": {\"name\": \"payments\"}}\n]",
+			topics: []string{"orders", "payments"},
+			lines:  []int{2, 3},
+		},
+		{
+			name:   "stream",
+			source: "{\"topic\": {\"name\": \"orders\"}}\n\n{\"topic\": {\"name\": \"payments\"}}\n",
+			topics: []string{"orders", "payments"},
+			lines:  []int{1, 3},
+		},
+		{
+			name:   "stream of arrays and objects",
+			source: "[{\"topic\": {\"name\": \"orders\"}}]\n{\"topic\": {\"name\": \"payments\"}}",
+			topics: []string{"orders", "payments"},
+			lines:  []int{1, 2},
+		},
+		{
+			name:   "empty",
+			source: "  \n",
+			topics: []string{},
+			lines:  []int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := DecodeJSON(strings.NewReader(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(entries) != len(test.topics) {
+				t.Fatalf("decoded %d entries, expected %d", len(entries), len(test.topics))
+			}
+
+			for index, entry := range entries {
+				if entry.Topic[EntryKeyTopicName] != test.topics[index] {
+					t.Errorf("entry %d has the topic %q, expected %q", index, entry.Topic[EntryKeyTopicName], test.topics[index])
+				}
+
+				if entry.Document != index+1 {
+					t.Errorf("entry %d has the document %d, expected %d", index, entry.Document, index+1)
+				}
+
+				if entry.Line != test.lines[index] {
+					t.Errorf("entry %d starts at line %d, expected %d", index, entry.Line, test.lines[index])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJSONNumbers(t *testing.T) {
+	entries, err := DecodeJSON(strings.NewReader(`{"topic": {"name": "orders", "partitions": 12}, "config": {"retention.ms": 86400000}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entries[0].Topic[EntryKeyTopicPartitionSize] != "12" {
+		t.Errorf("unexpected partitions %q", entries[0].Topic[EntryKeyTopicPartitionSize])
+	}
+
+	if value(entries[0].Config["retention.ms"]) != "86400000" {
+		t.Errorf("unexpected retention %q", value(entries[0].Config["retention.ms"]))
+	}
+}
+
+func TestDecodeJSONInvalid(t *testing.T) {
+	_, err := DecodeJSON(strings.NewReader(`{"topic": {"name": "orders"}`))
+	if err == nil {
+		t.Error("expected a error for a truncated document")
+	}
+}
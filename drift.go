@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Kinds of drift
+const (
+	DriftMissing   = "missing"
+	DriftUnmanaged = "unmanaged"
+	DriftModified  = "modified"
+)
+
+// Drift reporting templates
+const (
+	DriftTopicMissing   = "  + %s is defined but does not exist on the cluster\n"
+	DriftTopicUnmanaged = "  - %s exists on the cluster but is not defined in any configuration entry\n"
+	DriftTopicModified  = "  ~ %s\n"
+	DriftPartitions     = "      ~ partitions: %d on the cluster, %d defined\n"
+	DriftReplication    = "      ~ replication: %d on the cluster, %d defined\n"
+	DriftConfig         = "      ~ %s: %s on the cluster, %s defined\n"
+	DriftNotSet         = "(not set)"
+	DriftSummary        = "Drift: %d topics differ from their configuration entries, %d in sync.\n"
+	DriftNone           = "No drift. The Kafka cluster matches the topic configuration entries.\n"
+	DriftDetected       = "drift detected between the Kafka cluster and the topic configuration entries"
+)
+
+// ErrDriftDetected is returned when the Kafka cluster does not match the topic configuration entries
+var ErrDriftDetected = errors.New(DriftDetected)
+
+// TopicDrift represents the differences between a live topic and its configuration entry
+type TopicDrift struct {
+	Name               string
+	Kind               string
+	Source             string
+	CurrentPartitions  int32
+	NumPartitions      int32
+	CurrentReplication int16
+	ReplicationFactor  int16
+	Changes            []ConfigChange
+}
+
+// Drift compares the live topics against the topic entries without modifying the Kafka cluster.
+// Topics that are not defined are only reported in strict mode. The found drift is returned
+// ordered by topic name. The migration has to be prepared before drift could be detected.
+func (migration *Migration) Drift() ([]TopicDrift, error) {
+	drifts := []TopicDrift{}
+
+	existing := []string{}
+	for _, name := range sortedTopicNames(migration.TopicEntries) {
+		if _, exists := migration.Topics[name]; exists {
+			existing = append(existing, name)
+		}
+	}
+
+	configs, err := migration.client.DescribeConfigurations(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedTopicNames(migration.TopicEntries) {
+		topic := migration.TopicEntries[name]
+		drift := TopicDrift{
+			Name:   name,
+			Kind:   DriftModified,
+			Source: topic.Source,
+		}
+
+		live, exists := migration.Topics[name]
+		if !exists {
+			drift.Kind = DriftMissing
+			drifts = append(drifts, drift)
+			continue
+		}
+
+		current := configs[name]
+		config := EnforceConfiguration(topic, current, topic.ConfigEntries, migration.StrictMode)
+		drift.Changes = DiffConfiguration(current, config)
+
+		if topic.NumPartitions > 0 && topic.NumPartitions != live.NumPartitions {
+			drift.CurrentPartitions = live.NumPartitions
+			drift.NumPartitions = topic.NumPartitions
+		}
+
+		if topic.ReplicationFactor > 0 && topic.ReplicationFactor != live.ReplicationFactor {
+			drift.CurrentReplication = live.ReplicationFactor
+			drift.ReplicationFactor = topic.ReplicationFactor
+		}
+
+		if len(drift.Changes) == 0 && drift.NumPartitions == 0 && drift.ReplicationFactor == 0 {
+			continue
+		}
+
+		drifts = append(drifts, drift)
+	}
+
+	for _, topic := range migration.marked {
+		drifts = append(drifts, TopicDrift{
+			Name: topic.Name,
+			Kind: DriftUnmanaged,
+		})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Name < drifts[j].Name
+	})
+
+	return drifts, nil
+}
+
+// WriteDrift writes a human readable report of the given drift to the given writer.
+// The total number of checked topic entries is used to report the topics that are in sync.
+func WriteDrift(w io.Writer, drifts []TopicDrift, total int) {
+	if len(drifts) == 0 {
+		fmt.Fprint(w, DriftNone)
+		return
+	}
+
+	for _, drift := range drifts {
+		switch drift.Kind {
+		case DriftMissing:
+			fmt.Fprintf(w, DriftTopicMissing, drift.Name)
+		case DriftUnmanaged:
+			fmt.Fprintf(w, DriftTopicUnmanaged, drift.Name)
+		default:
+			fmt.Fprintf(w, DriftTopicModified, drift.Name)
+		}
+
+		if len(drift.Source) > 0 {
+			fmt.Fprintf(w, PlanTopicSource, drift.Source)
+		}
+
+		if drift.NumPartitions > 0 {
+			fmt.Fprintf(w, DriftPartitions, drift.CurrentPartitions, drift.NumPartitions)
+		}
+
+		if drift.ReplicationFactor > 0 {
+			fmt.Fprintf(w, DriftReplication, drift.CurrentReplication, drift.ReplicationFactor)
+		}
+
+		for _, change := range drift.Changes {
+			fmt.Fprintf(w, DriftConfig, change.Key, driftValue(change.Key, change.Old), driftValue(change.Key, change.New))
+		}
+	}
+
+	synced := total
+	for _, drift := range drifts {
+		if drift.Kind != DriftUnmanaged {
+			synced--
+		}
+	}
+
+	fmt.Fprintf(w, DriftSummary, len(drifts), synced)
+}
+
+// driftValue returns the human readable representation of the given configuration value
+func driftValue(key string, v *string) string {
+	if v == nil {
+		return DriftNotSet
+	}
+
+	return Humanize(key, *v)
+}
@@ -1,15 +1,124 @@
 package main
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
 // EntryStatus represents a migration entry status
 type EntryStatus struct {
 	Success bool
 	Err     error
 	Topic   Topic
 	Content Entry
+	// Action is the planned action that has been preformed
+	Action string
+	// Duration is the time it took to preform the action
+	Duration time.Duration
 }
 
 // Entry represents a Kafka topic configuration entry
 type Entry struct {
-	Topic  map[string]string  `yaml:"topic"`
-	Config map[string]*string `yaml:"config"`
+	Topic   map[string]string  `yaml:"topic"`
+	Config  map[string]*string `yaml:"config"`
+	Overlay bool               `yaml:"overlay"`
+
+	// Environment restricts the entry to a single environment, the entry applies to all environments when empty
+	Environment string `yaml:"environment"`
+	// Environments holds the topic values and configuration properties overriding the entry per environment
+	Environments map[string]EnvironmentEntry `yaml:"environments"`
+	// Profile is the name of the profile the entry inherits from
+	Profile string `yaml:"profile"`
+
+	// Path is the path of the file that declared the entry
+	Path string `yaml:"-"`
+	// Document is the position of the entry inside the file, starting at 1
+	Document int `yaml:"-"`
+	// Line is the line at which the entry starts inside the file, 0 if unknown
+	Line int `yaml:"-"`
+	// Sections holds the line of every root key defined inside the entry, 0 if unknown
+	Sections map[string]int `yaml:"-"`
+	// Positions holds the line of every key defined inside a section prefixed with the section name
+	Positions map[string]int `yaml:"-"`
+	// Overlays holds the locations of all overlays merged into the entry
+	Overlays []string `yaml:"-"`
+}
+
+// Keys returns all root keys defined inside the entry in lexical order
+func (entry Entry) Keys() []string {
+	keys := make([]string, 0, len(entry.Sections))
+	for key := range entry.Sections {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// LineOf returns the line of the given root key or section prefixed key.
+// The line of the closest parent key is returned if the position of the key is unknown,
+// the line of the entry is returned if none of the parent keys are known.
+func (entry Entry) LineOf(key string) int {
+	for {
+		if line := entry.Positions[key]; line > 0 {
+			return line
+		}
+
+		if line := entry.Sections[key]; line > 0 {
+			return line
+		}
+
+		index := strings.LastIndex(key, ".")
+		if index < 0 {
+			break
+		}
+
+		key = key[:index]
+	}
+
+	return entry.Line
+}
+
+// Location returns a human readable reference to the file and document that declared the entry
+func (entry Entry) Location() string {
+	location := entry.Path
+	if entry.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, entry.Line)
+	}
+
+	return fmt.Sprintf("%s (document %d)", location, entry.Document)
+}
+
+// copy returns a copy of the entry that does not share its topic and config maps
+func (entry Entry) copy() Entry {
+	topic := make(map[string]string, len(entry.Topic))
+	for key, v := range entry.Topic {
+		topic[key] = v
+	}
+
+	config := make(map[string]*string, len(entry.Config))
+	for key, v := range entry.Config {
+		config[key] = v
+	}
+
+	entry.Topic = topic
+	entry.Config = config
+	entry.Overlays = append([]string{}, entry.Overlays...)
+	return entry
+}
+
+// Source returns the location of the entry including the locations of all merged overlays
+func (entry Entry) Source() string {
+	source := entry.Location()
+	if len(entry.Profile) > 0 {
+		source = fmt.Sprintf("%s, profile %s", source, entry.Profile)
+	}
+
+	for _, overlay := range entry.Overlays {
+		source = fmt.Sprintf("%s, overlay %s", source, overlay)
+	}
+
+	return source
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sort"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// EnvironmentEntry represents the topic values and configuration properties of a entry
+// that only apply to a single environment
+type EnvironmentEntry struct {
+	Topic  map[string]string  `yaml:"topic"`
+	Config map[string]*string `yaml:"config"`
+	// Keys holds all keys defined inside the environment block in lexical order
+	Keys []string `yaml:"-"`
+}
+
+// UnmarshalYAML decodes the given YAML node and records the keys defined inside the environment block
+func (environment *EnvironmentEntry) UnmarshalYAML(node *yaml.Node) error {
+	type plain EnvironmentEntry
+	err := node.Decode((*plain)(environment))
+	if err != nil {
+		return err
+	}
+
+	environment.Keys = []string{}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		environment.Keys = append(environment.Keys, node.Content[index].Value)
+	}
+
+	sort.Strings(environment.Keys)
+	return nil
+}
+
+// Select returns the entries that apply to the given environment.
+// Entries without a environment apply to all environments.
+func Select(entries []Entry, environment string) []Entry {
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if len(entry.Environment) > 0 && entry.Environment != environment {
+			continue
+		}
+
+		result = append(result, entry)
+	}
+
+	return result
+}
+
+// Resolve returns a copy of the entry with the values of the given environment applied.
+// Environment values override the values of the entry, a configuration property defined without
+// a value removes the property. The entry is returned as is when the environment is not defined.
+func (entry Entry) Resolve(environment string) Entry {
+	overrides, has := entry.Environments[environment]
+	if !has || len(environment) == 0 {
+		return entry
+	}
+
+	resolved := entry.copy()
+
+	for key, v := range overrides.Topic {
+		resolved.Topic[key] = v
+	}
+
+	for key, v := range overrides.Config {
+		// Overlays keep the removal in order to remove the property from the base entry
+		if v == nil && !resolved.Overlay {
+			delete(resolved.Config, key)
+			continue
+		}
+
+		resolved.Config[key] = v
+	}
+
+	return resolved
+}
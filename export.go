@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Export messages
+const (
+	ExportWritten     = "Exported the topics %v to %s\n"
+	ExportFileExists  = "the file %s already exists, export into a empty directory or remove the file"
+	ExportFileEscapes = "the file %s of the topics %v is outside of the export directory %s"
+)
+
+// ExportOptions represents the options used to export the topics of a Kafka cluster
+type ExportOptions struct {
+	// Directory is the directory to which the topic files are written
+	Directory string
+	// GroupSeparator groups the topics sharing the prefix before the separator into a single file,
+	// every topic is written to its own file when empty
+	GroupSeparator string
+}
+
+// ExportedTopic represents a exported topic configuration entry
+type ExportedTopic struct {
+	Topic  ExportedTopicValues `yaml:"topic"`
+	Config map[string]string   `yaml:"config,omitempty"`
+}
+
+// ExportedTopicValues represents the topic section of a exported topic configuration entry
+type ExportedTopicValues struct {
+	Name        string `yaml:"name"`
+	Partitions  int32  `yaml:"partitions"`
+	Replication int16  `yaml:"replication"`
+}
+
+// Export writes the managed topics of the Kafka cluster as topic configuration files.
+// Only non-default and non read-only configuration properties are exported, durations and sizes
+// are written in human readable units. System topics, ignored topics and topics outside the
+// managed scope are not exported. The paths of the written files are returned.
+func (migration *Migration) Export(options ExportOptions) ([]string, error) {
+	groups := map[string][]string{}
+	ordered := []string{}
+
+	for _, name := range sortedTopicNames(migration.Topics) {
+		if strings.HasPrefix(name, "__") || !migration.Manages(name) || MatchPatterns(migration.Ignore, name) {
+			continue
+		}
+
+		group := name
+		if len(options.GroupSeparator) > 0 {
+			group = strings.SplitN(name, options.GroupSeparator, 2)[0]
+		}
+
+		group = exportFileName(group)
+
+		if len(groups[group]) == 0 {
+			ordered = append(ordered, group)
+		}
+
+		groups[group] = append(groups[group], name)
+	}
+
+	written := make([]string, 0, len(ordered))
+
+	names := []string{}
+	for _, group := range ordered {
+		names = append(names, groups[group]...)
+	}
+
+	configs, err := migration.client.DescribeConfigurations(names)
+	if err != nil {
+		return written, err
+	}
+
+	err = os.MkdirAll(options.Directory, 0755)
+	if err != nil {
+		return written, err
+	}
+
+	for _, group := range ordered {
+		path := filepath.Join(options.Directory, group+".yaml")
+
+		rel, err := filepath.Rel(options.Directory, path)
+		if err != nil || rel != filepath.Base(path) {
+			return written, fmt.Errorf(ExportFileEscapes, path, groups[group], options.Directory)
+		}
+
+		_, err = os.Stat(path)
+		if err == nil {
+			return written, fmt.Errorf(ExportFileExists, path)
+		}
+
+		buffer := bytes.Buffer{}
+		enc := yaml.NewEncoder(&buffer)
+		enc.SetIndent(2)
+
+		for _, name := range groups[group] {
+			topic := migration.Topics[name]
+			config := configs[name]
+
+			exported := ExportedTopic{
+				Topic: ExportedTopicValues{
+					Name:        topic.Name,
+					Partitions:  topic.NumPartitions,
+					Replication: topic.ReplicationFactor,
+				},
+			}
+
+			if len(config) > 0 {
+				exported.Config = make(map[string]string, len(config))
+			}
+
+			// Values are escaped to prevent them from being rendered as expressions when scanned
+			for key, v := range config {
+				exported.Config[key] = strings.Replace(Humanize(key, value(v)), "${", "$${", -1)
+			}
+
+			err = enc.Encode(exported)
+			if err != nil {
+				return written, err
+			}
+		}
+
+		err = enc.Close()
+		if err != nil {
+			return written, err
+		}
+
+		err = ioutil.WriteFile(path, buffer.Bytes(), 0644)
+		if err != nil {
+			return written, err
+		}
+
+		log.Printf(ExportWritten, groups[group], path)
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+// exportFileName returns the file name without extension of the given topic group. Leading dots are
+// stripped as hidden files are never scanned and path separators are replaced by underscores.
+func exportFileName(group string) string {
+	name := strings.TrimLeft(group, ".")
+	name = strings.Map(func(char rune) rune {
+		if char == '/' || char == '\\' || char == os.PathSeparator {
+			return '_'
+		}
+
+		return char
+	}, name)
+
+	if len(name) == 0 {
+		return "_"
+	}
+
+	return name
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExportFileName(t *testing.T) {
+	tests := []struct {
+		group string
+		want  string
+	}{
+		{group: "orders", want: "orders"},
+		{group: "orders.created", want: "orders.created"},
+		{group: ".orders", want: "orders"},
+		{group: "..", want: "_"},
+		{group: "../../etc/passwd", want: "_.._etc_passwd"},
+		{group: `orders\created`, want: "orders_created"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.group, func(t *testing.T) {
+			got := exportFileName(test.group)
+			if got != test.want {
+				t.Errorf("unexpected file name %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of findings
+const (
+	FindingValidation = "validation"
+	FindingLint       = "lint"
+	FindingPolicy     = "policy"
+	FindingBroker     = "broker"
+)
+
+// Findings reporting templates
+const (
+	FindingsSuite       = "kafkat"
+	FindingsToolURI     = "https://github.com/2Jours/topics"
+	FindingsSARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+	FindingsSARIF       = "2.1.0"
+	FindingsWritten     = "Wrote the %s report to %s\n"
+	FindingsWriteFailed = "unable to write the %s report to %s: %s\n"
+	FindingsMessage     = "%s: %s"
+	FindingsLine        = FindingsMessage + "\n"
+)
+
+// SARIF result levels
+const (
+	SARIFLevelError   = "error"
+	SARIFLevelWarning = "warning"
+)
+
+// Finding represents a single problem found for a topic definition or topic configuration file
+type Finding struct {
+	Kind string
+	// Rule is the name of the violated policy rule, empty for other kinds of findings
+	Rule     string
+	Severity string
+	// Topic is the name of the topic the finding belongs to, empty if unknown
+	Topic   string
+	Path    string
+	Line    int
+	Message string
+}
+
+// RuleID returns the identifier of the rule that produced the finding
+func (finding Finding) RuleID() string {
+	if len(finding.Rule) > 0 {
+		return finding.Kind + "/" + finding.Rule
+	}
+
+	return finding.Kind
+}
+
+// Location returns a reference to the file and line of the finding relative to the current directory
+func (finding Finding) Location() string {
+	if finding.Line > 0 {
+		return fmt.Sprintf("%s:%d", artifactURI(finding.Path), finding.Line)
+	}
+
+	return artifactURI(finding.Path)
+}
+
+// Findings collects the checked topic definitions and all problems found during a single run.
+// The findings could be written as JUnit XML and SARIF reports for CI systems.
+type Findings struct {
+	// Topics holds the checked topic definitions by name
+	Topics map[string]Topic
+	// Problems holds the found problems in the order they were found
+	Problems []Finding
+}
+
+// AddValidation adds the given validation or lint errors as findings of the given kind
+func (findings *Findings) AddValidation(kind string, errs ValidationErrors) {
+	for _, err := range errs {
+		findings.Problems = append(findings.Problems, Finding{
+			Kind:     kind,
+			Severity: SeverityError,
+			Topic:    err.Topic,
+			Path:     err.Path,
+			Line:     err.Line,
+			Message:  err.Message,
+		})
+	}
+}
+
+// AddPolicy adds the given policy violations, including warnings, as findings
+func (findings *Findings) AddPolicy(violations []PolicyViolation) {
+	for _, violation := range violations {
+		findings.Problems = append(findings.Problems, Finding{
+			Kind:     FindingPolicy,
+			Rule:     violation.Rule,
+			Severity: violation.Severity,
+			Topic:    violation.Topic,
+			Path:     violation.Path,
+			Line:     violation.Line,
+			Message:  violation.Message,
+		})
+	}
+}
+
+// AddStatuses adds the errors returned by the Kafka cluster for the given statuses as findings.
+// The location of a finding is the declaration of its topic.
+func (findings *Findings) AddStatuses(statuses []*EntryStatus) {
+	for _, status := range statuses {
+		if status.Err == nil {
+			continue
+		}
+
+		topic := findings.Topics[status.Topic.Name]
+		findings.Problems = append(findings.Problems, Finding{
+			Kind:     FindingBroker,
+			Severity: SeverityError,
+			Topic:    status.Topic.Name,
+			Path:     topic.Path,
+			Line:     topic.Line,
+			Message:  status.Err.Error(),
+		})
+	}
+}
+
+// Save writes the JUnit XML and SARIF reports to the given paths, a report is not written if its path is empty.
+// Problems are logged since the reports are also written when the run fails.
+func (findings *Findings) Save(junit, sarif string) {
+	reports := []struct {
+		Name  string
+		Path  string
+		Write func(io.Writer) error
+	}{
+		{"JUnit", junit, findings.WriteJUnit},
+		{"SARIF", sarif, findings.WriteSARIF},
+	}
+
+	for _, report := range reports {
+		if len(report.Path) == 0 {
+			continue
+		}
+
+		err := writeFile(report.Path, report.Write)
+		if err != nil {
+			log.Printf(FindingsWriteFailed, report.Name, report.Path, err)
+			continue
+		}
+
+		log.Printf(FindingsWritten, report.Name, report.Path)
+	}
+}
+
+// JUnitTestSuites represents the root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite represents a collection of test cases inside a JUnit XML report
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase represents a single topic definition inside a JUnit XML report
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure represents the failure of a test case
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the findings as JUnit XML report to the given writer. Every topic definition is
+// reported as test case which fails when a problem with the error severity is found for the topic,
+// warnings are reported as output of the test case. Problems that do not belong to a known topic
+// are reported as failed test cases named after their location.
+func (findings *Findings) WriteJUnit(w io.Writer) error {
+	suite := JUnitTestSuite{Name: FindingsSuite}
+	problems := map[string][]Finding{}
+	unknown := []Finding{}
+
+	for _, problem := range findings.Problems {
+		_, has := findings.Topics[problem.Topic]
+		if !has {
+			unknown = append(unknown, problem)
+			continue
+		}
+
+		problems[problem.Topic] = append(problems[problem.Topic], problem)
+	}
+
+	for _, name := range sortedTopicNames(findings.Topics) {
+		topic := findings.Topics[name]
+		suite.Cases = append(suite.Cases, junitTestCase(name, topic.Path, problems[name]))
+	}
+
+	for _, problem := range unknown {
+		suite.Cases = append(suite.Cases, junitTestCase(problem.Location(), problem.Path, []Finding{problem}))
+	}
+
+	for _, testcase := range suite.Cases {
+		suite.Tests++
+		if testcase.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	report := JUnitTestSuites{
+		Name:     FindingsSuite,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []JUnitTestSuite{suite},
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err = enc.Encode(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// junitTestCase constructs a test case for the given problems, the first error is used as failure message
+func junitTestCase(name, path string, problems []Finding) JUnitTestCase {
+	testcase := JUnitTestCase{
+		Name:      name,
+		ClassName: artifactURI(path),
+		File:      artifactURI(path),
+	}
+
+	failures := strings.Builder{}
+	warnings := strings.Builder{}
+
+	for _, problem := range problems {
+		line := fmt.Sprintf(FindingsLine, problem.Location(), problem.Message)
+
+		if problem.Severity == SeverityWarn {
+			warnings.WriteString(line)
+			continue
+		}
+
+		if testcase.Failure == nil {
+			testcase.Failure = &JUnitFailure{
+				Message: problem.Message,
+				Type:    problem.RuleID(),
+			}
+		}
+
+		failures.WriteString(line)
+	}
+
+	if testcase.Failure != nil {
+		testcase.Failure.Text = failures.String()
+	}
+
+	testcase.SystemOut = warnings.String()
+	return testcase
+}
+
+// SARIFLog represents the root object of a SARIF report
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun represents a single run of kafkat inside a SARIF report
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes kafkat and the rules that produced results
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver describes kafkat and the rules that produced results
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes a rule that produced results
+type SARIFRule struct {
+	ID               string       `json:"id"`
+	ShortDescription SARIFMessage `json:"shortDescription"`
+}
+
+// SARIFMessage represents a plain text message
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult represents a single finding annotated against a source file
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations,omitempty"`
+}
+
+// SARIFLocation represents the location of a result
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation represents the file and region of a result
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation represents the file of a result
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion represents the line of a result
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSARIF writes the findings as SARIF report to the given writer.
+// Every problem is reported as result annotated against its file and line.
+func (findings *Findings) WriteSARIF(w io.Writer) error {
+	run := SARIFRun{
+		Tool: SARIFTool{
+			Driver: SARIFDriver{
+				Name:           FindingsSuite,
+				InformationURI: FindingsToolURI,
+				Rules:          []SARIFRule{},
+			},
+		},
+		Results: []SARIFResult{},
+	}
+
+	rules := map[string]bool{}
+
+	for _, problem := range findings.Problems {
+		id := problem.RuleID()
+		if !rules[id] {
+			rules[id] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, SARIFRule{
+				ID:               id,
+				ShortDescription: SARIFMessage{Text: id},
+			})
+		}
+
+		result := SARIFResult{
+			RuleID:  id,
+			Level:   SARIFLevelError,
+			Message: SARIFMessage{Text: problem.Message},
+		}
+
+		if problem.Severity == SeverityWarn {
+			result.Level = SARIFLevelWarning
+		}
+
+		if len(problem.Topic) > 0 {
+			result.Message.Text = fmt.Sprintf(FindingsMessage, problem.Topic, problem.Message)
+		}
+
+		if len(problem.Path) > 0 {
+			location := SARIFLocation{}
+			location.PhysicalLocation.ArtifactLocation.URI = artifactURI(problem.Path)
+
+			if problem.Line > 0 {
+				location.PhysicalLocation.Region = &SARIFRegion{StartLine: problem.Line}
+			}
+
+			result.Locations = append(result.Locations, location)
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(SARIFLog{
+		Schema:  FindingsSARIFSchema,
+		Version: FindingsSARIF,
+		Runs:    []SARIFRun{run},
+	})
+}
+
+// artifactURI returns the given path as slash separated path relative to the current directory when possible
+func artifactURI(path string) string {
+	if filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err == nil {
+			relative, err := filepath.Rel(wd, path)
+			if err == nil && !strings.HasPrefix(relative, "..") {
+				path = relative
+			}
+		}
+	}
+
+	return filepath.ToSlash(path)
+}
+
+// writeFile creates the file at the given path and writes its content with the given function
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
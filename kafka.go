@@ -1,7 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
 )
@@ -10,23 +15,57 @@ import (
 const (
 	TopicFound              = "Found: %s, with the configuration %v\n"
 	TopicMarkedForDeletion  = "The topic: %s, is not defined in any configuration entry and is marked for deletion\n"
+	TopicIgnored            = "The topic: %s, is not defined in any configuration entry but matches a ignore pattern\n"
 	EntryPropertyNotDefined = "The configuration property: %s on the topic %s, is not defined in the configuration entry\n"
 	EntryPropertyDeleted    = "The configuration property: %s on the topic %s, is marked for deletion\n"
 	AlteredConfiguration    = "The configuration for the topic %s, has been modified %+v\n"
+	CreatedPartitions       = "The number of partitions for the topic %s, has been increased to %d\n"
+	ReassignedPartitions    = "The replicas of the topic %s, are being reassigned to %v\n"
+	ConnectionFailed        = "unable to connect to the Kafka cluster %v: %s"
+	TopicCreateFailed       = "%s: %s"
+	TopicDescribeFailed     = "unable to describe the configuration of the topic %s: %s"
 )
 
-// NewKafkaAdmin creates a new KafkaAdmin
-func NewKafkaAdmin(brokers []string, version sarama.KafkaVersion) (*KafkaAdmin, error) {
+// ConnectionError represents a failure to connect to the Kafka cluster
+type ConnectionError struct {
+	Brokers []string
+	Err     error
+}
+
+func (err *ConnectionError) Error() string {
+	return fmt.Sprintf(ConnectionFailed, err.Brokers, err.Err)
+}
+
+// IsConnectionError returns true if the given error is caused by a unreachable Kafka cluster
+func IsConnectionError(err error) bool {
+	switch err.(type) {
+	case *ConnectionError, net.Error:
+		return true
+	}
+
+	return err == sarama.ErrOutOfBrokers || err == sarama.ErrNotConnected || err == sarama.ErrClosedClient
+}
+
+// NewKafkaAdmin creates a new KafkaAdmin connected to the given cluster
+func NewKafkaAdmin(cluster Cluster) (*KafkaAdmin, error) {
 	config := sarama.NewConfig()
-	config.Version = version
 
-	admin, err := sarama.NewClusterAdmin(brokers, config)
+	err := cluster.Configure(config)
 	if err != nil {
 		return nil, err
 	}
 
+	admin, err := sarama.NewClusterAdmin(cluster.Brokers, config)
+	if err != nil {
+		return nil, &ConnectionError{Brokers: cluster.Brokers, Err: err}
+	}
+
 	client := KafkaAdmin{
-		client: admin,
+		KafkaVersion: config.Version,
+		Brokers:      cluster.Brokers,
+		BatchSize:    cluster.BatchSize,
+		client:       admin,
+		config:       config,
 	}
 
 	return &client, nil
@@ -37,78 +76,322 @@ func NewKafkaAdmin(brokers []string, version sarama.KafkaVersion) (*KafkaAdmin,
 type KafkaAdmin struct {
 	KafkaVersion sarama.KafkaVersion
 	Brokers      []string
-	client       sarama.ClusterAdmin
+	// BatchSize is the maximum number of topics inside a single request, the default is used when not set
+	BatchSize int
+	client    sarama.ClusterAdmin
+	config    *sarama.Config
+	metadata  sarama.Client
+	mutex     sync.Mutex
+}
+
+// connect returns the client used for requests that are not supported by the cluster admin.
+// The client is connected on first use.
+func (kafka *KafkaAdmin) connect() (sarama.Client, error) {
+	kafka.mutex.Lock()
+	defer kafka.mutex.Unlock()
+
+	if kafka.metadata != nil {
+		return kafka.metadata, nil
+	}
+
+	client, err := sarama.NewClient(kafka.Brokers, kafka.config)
+	if err != nil {
+		return nil, &ConnectionError{Brokers: kafka.Brokers, Err: err}
+	}
+
+	kafka.metadata = client
+	return client, nil
+}
+
+// batchSize returns the maximum number of topics inside a single request
+func (kafka *KafkaAdmin) batchSize() int {
+	if kafka.BatchSize > 0 {
+		return kafka.BatchSize
+	}
+
+	return DefaultBatchSize
+}
+
+// CreateTopics creates the given topics with their replication factor, number of partitions and
+// configuration entries. The topics are send to the controller in batches of at most the batch size.
+// In validate mode the topics are only validated by the Kafka cluster. The errors of the topics that
+// could not be created are returned by topic name, a empty map is returned when all topics are created.
+func (kafka *KafkaAdmin) CreateTopics(topics []Topic, validate bool) map[string]error {
+	errs := make(map[string]error)
+	size := kafka.batchSize()
+
+	for start := 0; start < len(topics); start += size {
+		end := start + size
+		if end > len(topics) {
+			end = len(topics)
+		}
+
+		for name, err := range kafka.createTopics(topics[start:end], validate) {
+			errs[name] = err
+		}
+	}
+
+	return errs
+}
+
+// createTopics creates or validates the given topics with a single request to the controller
+func (kafka *KafkaAdmin) createTopics(topics []Topic, validate bool) map[string]error {
+	errs := make(map[string]error)
+
+	fail := func(err error) map[string]error {
+		for _, topic := range topics {
+			errs[topic.Name] = err
+		}
+
+		return errs
+	}
+
+	request := &sarama.CreateTopicsRequest{
+		TopicDetails: make(map[string]*sarama.TopicDetail, len(topics)),
+		ValidateOnly: validate,
+		Timeout:      kafka.config.Admin.Timeout,
+	}
+
+	if kafka.KafkaVersion.IsAtLeast(sarama.V0_11_0_0) {
+		request.Version = 1
+	}
+
+	if kafka.KafkaVersion.IsAtLeast(sarama.V1_0_0_0) {
+		request.Version = 2
+	}
+
+	for _, topic := range topics {
+		request.TopicDetails[topic.Name] = &sarama.TopicDetail{
+			NumPartitions:     topic.NumPartitions,
+			ReplicationFactor: topic.ReplicationFactor,
+			ConfigEntries:     topic.ConfigEntries,
+		}
+	}
+
+	client, err := kafka.connect()
+	if err != nil {
+		return fail(err)
+	}
+
+	controller, err := client.Controller()
+	if err != nil {
+		return fail(err)
+	}
+
+	response, err := controller.CreateTopics(request)
+	if err != nil {
+		return fail(err)
+	}
+
+	for _, topic := range topics {
+		result, has := response.TopicErrors[topic.Name]
+
+		switch {
+		case !has:
+			errs[topic.Name] = sarama.ErrIncompleteResponse
+		case result.Err == sarama.ErrNoError:
+			continue
+		case result.ErrMsg != nil && len(*result.ErrMsg) > 0:
+			errs[topic.Name] = fmt.Errorf(TopicCreateFailed, result.Err, *result.ErrMsg)
+		default:
+			errs[topic.Name] = result.Err
+		}
+	}
+
+	return errs
 }
 
-// CreateTopic creates a new Kafka topic with the given topic replication factor
-// and number of partitions.
-func (kafka *KafkaAdmin) CreateTopic(topic Topic) error {
-	details := sarama.TopicDetail{
-		NumPartitions:     topic.NumPartitions,
-		ReplicationFactor: topic.ReplicationFactor,
+// CreatePartitions increases the number of partitions of the given topic
+// to the number of partitions defined on the topic.
+func (kafka *KafkaAdmin) CreatePartitions(topic Topic) error {
+	err := kafka.client.CreatePartitions(topic.Name, topic.NumPartitions, nil, false)
+	if err != nil {
+		return err
 	}
 
-	// Validate the topic configuration
-	valid := kafka.client.CreateTopic(topic.Name, &details, true)
-	if valid != nil {
-		return valid
+	log.Printf(CreatedPartitions, topic.Name, topic.NumPartitions)
+	return nil
+}
+
+// ValidatePartitions validates the increase of the number of partitions
+// of the given topic without preforming it.
+func (kafka *KafkaAdmin) ValidatePartitions(topic Topic) error {
+	return kafka.client.CreatePartitions(topic.Name, topic.NumPartitions, nil, true)
+}
+
+// SupportsReassignments returns true if the Kafka cluster accepts partition reassignments through the admin API
+func (kafka *KafkaAdmin) SupportsReassignments() bool {
+	return kafka.KafkaVersion.IsAtLeast(sarama.V2_4_0_0)
+}
+
+// ReassignPartitions submits the given replica assignment of the given topic by partition. The assignment
+// should contain all partitions of the topic. The reassignment is preformed by the Kafka cluster in the
+// background, use PendingReassignments to track its progress.
+func (kafka *KafkaAdmin) ReassignPartitions(topic Topic, assignment map[int32][]int32) error {
+	replicas := make([][]int32, len(assignment))
+	for partition := range replicas {
+		replicas[partition] = assignment[int32(partition)]
 	}
 
-	err := kafka.client.CreateTopic(topic.Name, &details, false)
+	err := kafka.client.AlterPartitionReassignments(topic.Name, replicas)
 	if err != nil {
 		return err
 	}
 
+	log.Printf(ReassignedPartitions, topic.Name, replicas)
 	return nil
 }
 
-// EnforceConfiguration enforces the given configuration for undefined existing properties
-func (kafka *KafkaAdmin) EnforceConfiguration(topic Topic, config map[string]*string, strict bool) (map[string]*string, error) {
-	description, err := kafka.client.DescribeConfig(sarama.ConfigResource{
-		Type: sarama.TopicResource,
-		Name: topic.Name,
-	})
+// PendingReassignments returns the number of the given partitions of the given topic
+// that are still being reassigned by the Kafka cluster
+func (kafka *KafkaAdmin) PendingReassignments(topic string, partitions []int32) (int, error) {
+	statuses, err := kafka.client.ListPartitionReassignments(topic, partitions)
+	if err != nil {
+		return 0, err
+	}
 
+	return len(statuses[topic]), nil
+}
+
+// DescribeConfigurations returns the configuration properties that are explicitly set on the given topics
+// by topic name. Default and read only properties are ignored. The topics are described in batches of at
+// most the batch size which are spread over all available brokers, the brokers are requested concurrently.
+func (kafka *KafkaAdmin) DescribeConfigurations(names []string) (map[string]map[string]*string, error) {
+	configs := make(map[string]map[string]*string, len(names))
+	if len(names) == 0 {
+		return configs, nil
+	}
+
+	client, err := kafka.connect()
 	if err != nil {
-		return config, err
+		return nil, err
 	}
 
-	for _, entry := range description {
-		if entry.Default || entry.ReadOnly {
-			continue
+	brokers := client.Brokers()
+	if len(brokers) == 0 {
+		return nil, sarama.ErrOutOfBrokers
+	}
+
+	sort.Slice(brokers, func(i, j int) bool {
+		return brokers[i].ID() < brokers[j].ID()
+	})
+
+	// Batches are distributed round robin, every broker handles its batches one at a time
+	assigned := make([][][]string, len(brokers))
+	size := kafka.batchSize()
+
+	for start := 0; start < len(names); start += size {
+		end := start + size
+		if end > len(names) {
+			end = len(names)
 		}
 
-		_, has := config[entry.Name]
-		if has {
+		index := (start / size) % len(brokers)
+		assigned[index] = append(assigned[index], names[start:end])
+	}
+
+	mutex := sync.Mutex{}
+	group := sync.WaitGroup{}
+	errs := make([]error, len(brokers))
+
+	for index, broker := range brokers {
+		if len(assigned[index]) == 0 {
 			continue
 		}
 
-		log.Printf(EntryPropertyNotDefined, entry.Name, topic.Name)
+		group.Add(1)
+
+		go func(index int, broker *sarama.Broker) {
+			defer group.Done()
+
+			for _, batch := range assigned[index] {
+				described, err := kafka.describeConfigurations(broker, batch)
+				if err != nil {
+					errs[index] = err
+					return
+				}
+
+				mutex.Lock()
+				for name, config := range described {
+					configs[name] = config
+				}
+				mutex.Unlock()
+			}
+		}(index, broker)
+	}
 
-		if strict {
-			log.Printf(EntryPropertyDeleted, entry.Name, topic.Name)
-			continue
+	group.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	return configs, nil
+}
+
+// describeConfigurations describes the configuration of the given topics with a single request to the given broker
+func (kafka *KafkaAdmin) describeConfigurations(broker *sarama.Broker, names []string) (map[string]map[string]*string, error) {
+	// Brokers known by the client are not connected until used, opening a connected broker has no effect
+	broker.Open(kafka.config)
+
+	request := &sarama.DescribeConfigsRequest{
+		Resources: make([]*sarama.ConfigResource, 0, len(names)),
+	}
+
+	for _, name := range names {
+		request.Resources = append(request.Resources, &sarama.ConfigResource{
+			Type: sarama.TopicResource,
+			Name: name,
+		})
+	}
 
-		config[entry.Name] = &entry.Value
+	response, err := broker.DescribeConfigs(request)
+	if err != nil {
+		return nil, err
 	}
 
-	return config, nil
+	configs := make(map[string]map[string]*string, len(names))
+
+	for _, resource := range response.Resources {
+		if len(resource.ErrorMsg) > 0 {
+			return nil, fmt.Errorf(TopicDescribeFailed, resource.Name, resource.ErrorMsg)
+		}
+
+		if resource.ErrorCode != int16(sarama.ErrNoError) {
+			return nil, fmt.Errorf(TopicDescribeFailed, resource.Name, sarama.KError(resource.ErrorCode))
+		}
+
+		config := make(map[string]*string, len(resource.Configs))
+
+		for _, entry := range resource.Configs {
+			if entry.Default || entry.ReadOnly {
+				continue
+			}
+
+			value := entry.Value
+			config[entry.Name] = &value
+		}
+
+		configs[resource.Name] = config
+	}
+
+	for _, name := range names {
+		if _, has := configs[name]; !has {
+			return nil, sarama.ErrIncompleteResponse
+		}
+	}
+
+	return configs, nil
 }
 
 // AlterConfiguration alters the configuration of the given Topic.
-// If strict mode is enable are all topic configurations
-// that are not defined in the given configuration deleted.
+// The given configuration replaces all explicitly set configuration properties.
 // No validation is preformed before a configuration alteration
 // and it is not checked if the given topic already exists on the Kafka cluster.
-func (kafka *KafkaAdmin) AlterConfiguration(topic Topic, config map[string]*string, strict bool) error {
-	var err error
-	config, err = kafka.EnforceConfiguration(topic, config, strict)
-	if err != nil {
-		return err
-	}
-
-	err = kafka.client.AlterConfig(sarama.TopicResource, topic.Name, config, false)
+func (kafka *KafkaAdmin) AlterConfiguration(topic Topic, config map[string]*string) error {
+	err := kafka.client.AlterConfig(sarama.TopicResource, topic.Name, config, false)
 	if err != nil {
 		return err
 	}
@@ -119,14 +402,8 @@ func (kafka *KafkaAdmin) AlterConfiguration(topic Topic, config map[string]*stri
 
 // ValidateConfiguration validates the given configuration.
 // A error is returned if the configuration is invalid.
-func (kafka *KafkaAdmin) ValidateConfiguration(topic Topic, config map[string]*string, strict bool) error {
-	var err error
-	config, err = kafka.EnforceConfiguration(topic, config, strict)
-	if err != nil {
-		return err
-	}
-
-	err = kafka.client.AlterConfig(sarama.TopicResource, topic.Name, config, true)
+func (kafka *KafkaAdmin) ValidateConfiguration(topic Topic, config map[string]*string) error {
+	err := kafka.client.AlterConfig(sarama.TopicResource, topic.Name, config, true)
 	if err != nil {
 		return err
 	}
@@ -150,6 +427,7 @@ func (kafka *KafkaAdmin) ListTopics() (map[string]Topic, error) {
 			ConfigEntries:     topic.ConfigEntries,
 			NumPartitions:     topic.NumPartitions,
 			ReplicationFactor: topic.ReplicationFactor,
+			ReplicaAssignment: topic.ReplicaAssignment,
 		}
 
 		// For readability parse the map pointers to strings
@@ -174,3 +452,105 @@ func (kafka *KafkaAdmin) DeleteTopic(topic Topic) error {
 
 	return nil
 }
+
+// DescribeBrokers returns the ids of all available brokers inside the Kafka cluster sorted in ascending order
+func (kafka *KafkaAdmin) DescribeBrokers() ([]int32, error) {
+	brokers, _, err := kafka.client.DescribeCluster()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int32, 0, len(brokers))
+	for _, broker := range brokers {
+		ids = append(ids, broker.ID())
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return ids, nil
+}
+
+// ActiveConsumerGroups returns the consumer groups that have members assigned to one of the given topics
+// grouped by topic name.
+func (kafka *KafkaAdmin) ActiveConsumerGroups(topics []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+
+	listed, err := kafka.client.ListConsumerGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(listed) == 0 {
+		return result, nil
+	}
+
+	groups := make([]string, 0, len(listed))
+	for group := range listed {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	descriptions, err := kafka.client.DescribeConsumerGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		lookup[topic] = true
+	}
+
+	for _, group := range descriptions {
+		assigned := make(map[string]bool)
+
+		for _, member := range group.Members {
+			assignment, err := member.GetMemberAssignment()
+			if err != nil || assignment == nil {
+				continue
+			}
+
+			for topic := range assignment.Topics {
+				if !lookup[topic] || assigned[topic] {
+					continue
+				}
+
+				assigned[topic] = true
+				result[topic] = append(result[topic], group.GroupId)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// RecentlyWritten returns true if one of the partitions of the given topic received writes after the given time
+func (kafka *KafkaAdmin) RecentlyWritten(topic Topic, since time.Time) (bool, error) {
+	client, err := kafka.connect()
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := since.UnixNano() / int64(time.Millisecond)
+
+	for partition := int32(0); partition < topic.NumPartitions; partition++ {
+		newest, err := client.GetOffset(topic.Name, partition, sarama.OffsetNewest)
+		if err != nil {
+			return false, err
+		}
+
+		// The offset of the first message written at or after the given timestamp
+		offset, err := client.GetOffset(topic.Name, partition, timestamp)
+		if err != nil {
+			return false, err
+		}
+
+		if offset >= 0 && offset < newest {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -3,83 +3,511 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"mime"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Supported configuration types
 const (
 	TypeYAML = "text/yaml; charset=utf-8"
+	TypeJSON = "application/json"
+	TypeTOML = "application/toml"
+	TypeHCL  = "application/hcl"
 )
 
 // Flag variables
 var (
-	TargetPath   = ""
-	Brokers      = ""
-	KafkaVersion = ""
-	StrictMode   = false
-	ValidateMode = false
+	TargetPaths      = StringsFlag{}
+	Include          = StringsFlag{}
+	Exclude          = StringsFlag{}
+	Brokers          = ""
+	KafkaVersion     = ""
+	BatchSize        = 0
+	StrictMode       = false
+	ValidateMode     = false
+	PlanMode         = false
+	StrictExtensions = false
+	OfflineMode      = false
+	PolicyFile       = ""
+	PlanOut          = ""
+	PlanFile         = ""
+
+	ReassignmentFile = ""
+
+	MaxDeletions = 0
+	Protected    = PatternsFlag{}
+	RecentWrites = time.Duration(0)
+	AutoApprove  = false
+	Parallelism  = 1
+
+	ProjectFile  = ""
+	IgnoreTopics = PatternsFlag{}
+	IgnorePreset = StringsFlag{}
+	Managed      = StringsFlag{}
+
+	Auth        = Authentication{}
+	ClusterName = ""
+	Environment = ""
+
+	VariableFiles = StringsFlag{}
+	Variables     = VariablesFlag{}
+
+	ExportGroup = ""
+	Output      = ""
+	JUnitReport = ""
+	SARIFReport = ""
+)
+
+// Available commands, the apply command is used when no command is given
+const (
+	CommandApply  = "apply"
+	CommandExport = "export"
+	CommandDrift  = "drift"
+)
+
+// Command messages
+const (
+	UnknownCommand = "unknown command %q, expected %s, %s or %s"
+)
+
+// Exit codes
+const (
+	ExitSuccess = 0
+	ExitError   = 1
+	ExitDrift   = 2
+	// ExitValidation is used when a topic file, policy or validation by the Kafka cluster failed
+	ExitValidation = 3
+	// ExitPartialApply is used when one or more planned actions failed
+	ExitPartialApply = 4
+	// ExitConnection is used when the Kafka cluster could not be reached
+	ExitConnection = 5
 )
 
 // Reporting templates
 const (
 	devider         = "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
-	HeaderReport    = devider + "\tKafka bootstrap brokers:\t%s\n\tValidate mode:\t\t\t%t\n\tStrict mode:\t\t\t%t\n\tTarget path:\t\t\t%s\n" + devider
-	MigrationReport = devider + "\tValidate mode:\t\t\t%t\n\tStrict mode:\t\t\t%t\n\tKafka bootstrap brokers:\t%s\n\tTopics found:\t\t\t%v\n\tTopics entries:\t\t\t%v\n\tTopics marked for deletion:\t%v\n" + devider
+	HeaderReport    = devider + "\tKafka cluster:\t\t\t%s\n\tEnvironment:\t\t\t%s\n\tValidate mode:\t\t\t%t\n\tStrict mode:\t\t\t%t\n\tPlan mode:\t\t\t%t\n\tTarget paths:\t\t\t%v\n" + devider
+	OfflineReport   = "All topic configurations are valid for Kafka %s, %d topics checked\n"
+	ExportReport    = "Exported %d topic files to %s\n"
+	MigrationReport = devider + "\tValidate mode:\t\t\t%t\n\tStrict mode:\t\t\t%t\n\tKafka cluster:\t\t\t%s\n\tTopics found:\t\t\t%v\n\tTopics entries:\t\t\t%v\n\tTopics marked for deletion:\t%v\n" + devider
 )
 
 func init() {
-	// Include the yaml/yml type extentions to the default mime package
+	// Include the supported type extentions to the default mime package
 	mime.AddExtensionType(".yaml", TypeYAML)
 	mime.AddExtensionType(".yml", TypeYAML)
+	mime.AddExtensionType(".json", TypeJSON)
+	mime.AddExtensionType(".toml", TypeTOML)
+	mime.AddExtensionType(".hcl", TypeHCL)
+}
+
+// ExitCode returns the exit code of the given command for the given recovered error.
+// The drift command only distinguishes drift from any other error.
+func ExitCode(command string, err interface{}) int {
+	if command == CommandDrift && err != nil && err != ErrDriftDetected {
+		return ExitError
+	}
+
+	switch err := err.(type) {
+	case nil:
+		return ExitSuccess
+	case ValidationErrors, PolicyViolations:
+		return ExitValidation
+	case *ApplyError:
+		switch {
+		case err.ValidateMode:
+			return ExitValidation
+		case err.Disconnected():
+			return ExitConnection
+		}
+
+		return ExitPartialApply
+	case error:
+		switch {
+		case err == ErrDriftDetected:
+			return ExitDrift
+		case IsConnectionError(err):
+			return ExitConnection
+		}
+	}
+
+	return ExitError
 }
 
 func main() {
+	// The command is defined as the first argument
+	command, args := CommandApply, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
 	// Recover from panics and exit with a error
 	defer func() {
 		err := recover()
 		if err == nil {
-			os.Exit(0)
+			os.Exit(ExitSuccess)
 			return
 		}
 
-		fmt.Println(err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(ExitCode(command, err))
 	}()
 
-	flag.StringVar(&TargetPath, "target", ".", "Target directory, by default is the current directory used")
+	flag.Var(&TargetPaths, "target", "Target directory or file, could be defined multiple times. By default is the current directory used")
+	flag.Var(&Include, "include", "Glob pattern of files to include, could be defined multiple times")
+	flag.Var(&Exclude, "exclude", "Glob pattern of files or directories to exclude, could be defined multiple times")
+	flag.BoolVar(&StrictExtensions, "strict-extensions", false, "Fail when a scanned file has a unrecognised extension instead of ignoring it")
 	flag.StringVar(&Brokers, "brokers", "", "Initial Kafka broker hosts")
-	flag.StringVar(&KafkaVersion, "kafka-version", "1.1.0", "Initial Kafka broker hosts")
+	flag.StringVar(&KafkaVersion, "kafka-version", "", "Kafka protocol version used to communicate with the brokers, by default is "+DefaultKafkaVersion+" used")
 	flag.BoolVar(&StrictMode, "strict", false, "Strict configuration mode")
 	flag.BoolVar(&ValidateMode, "validate", false, "Validate mode")
-	flag.Parse()
+	flag.StringVar(&PolicyFile, "policy", "", "Policy file containing organisational rules all topic definitions have to comply with")
+	flag.BoolVar(&OfflineMode, "offline", false, "Offline mode, validates the topic configurations against the built-in configuration catalogue without connecting to the Kafka cluster")
+	flag.BoolVar(&PlanMode, "plan", false, "Plan mode, prints the planned changes without applying them")
+	flag.StringVar(&PlanOut, "out", "", "Write the computed plan to the given file")
+	flag.StringVar(&PlanFile, "plan-file", "", "Apply a previously saved plan file instead of scanning the target directory")
+	flag.StringVar(&ReassignmentFile, "reassignment-out", "", "Write replica reassignments for replication factor changes to the given file instead of submitting them, required for Kafka versions before 2.4.0")
+	flag.IntVar(&MaxDeletions, "max-deletions", 10, "Maximum number of topics deleted in a single run, a negative value disables the limit")
+	flag.Var(&Protected, "protected", "Glob or /regex/ pattern of topics that could never be deleted, could be defined multiple times")
+	flag.DurationVar(&RecentWrites, "recent-writes", 24*time.Hour, "Refuse to delete topics that received writes within the given duration, 0 disables the check")
+	flag.BoolVar(&AutoApprove, "auto-approve", false, "Skip the interactive confirmation of topic deletions")
+	flag.IntVar(&BatchSize, "batch-size", 0, "Maximum number of topics described or created inside a single request, by default is "+strconv.Itoa(DefaultBatchSize)+" used")
+	flag.IntVar(&Parallelism, "parallelism", 1, "Number of topics that are updated concurrently")
+	flag.StringVar(&ProjectFile, "project", "", "Project file, by default is "+strings.Join(DefaultProjectFiles, " or ")+" inside the current directory used when available")
+	flag.Var(&IgnoreTopics, "ignore", "Glob or /regex/ pattern of unmanaged topics that are never marked for deletion in strict mode, could be defined multiple times")
+	flag.Var(&IgnorePreset, "ignore-preset", "Ignore the internal topics of kafka-streams, connect, schema-registry or mirrormaker2, could be defined multiple times")
+	flag.Var(&Managed, "managed-prefix", "Topic name prefix managed by this project, only topics with a managed prefix are created, altered or deleted. Could be defined multiple times")
+	flag.BoolVar(&Auth.TLS.Enable, "tls", false, "Connect to the Kafka brokers over TLS")
+	flag.StringVar(&Auth.TLS.CAFile, "tls-ca", "", "PEM encoded CA certificates used to verify the Kafka brokers")
+	flag.StringVar(&Auth.TLS.CertFile, "tls-cert", "", "PEM encoded TLS client certificate")
+	flag.StringVar(&Auth.TLS.KeyFile, "tls-key", "", "PEM encoded TLS client key")
+	flag.BoolVar(&Auth.TLS.InsecureSkipVerify, "tls-insecure-skip-verify", false, "Skip the verification of the Kafka broker certificates")
+	flag.StringVar(&Auth.TLS.ServerName, "tls-server-name", "", "Server name used to verify the Kafka broker certificates")
+	flag.StringVar(&Auth.SASL.Mechanism, "sasl-mechanism", "", "SASL mechanism, PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER")
+	flag.StringVar(&Auth.SASL.Username, "sasl-username", "", "SASL username, could reference a environment variable (env:NAME) or file (file:path)")
+	flag.StringVar(&Auth.SASL.Password, "sasl-password", "", "SASL password, should reference a environment variable (env:NAME) or file (file:path)")
+	flag.StringVar(&Auth.SASL.TokenFile, "sasl-token-file", "", "File containing the OAUTHBEARER access token, read on every authentication")
+	flag.StringVar(&ClusterName, "cluster", "", "Cluster profile defined inside the project file, by default is the "+ClusterEnv+" environment variable used")
+	flag.StringVar(&Environment, "environment", "", "Environment for which the topic entries are resolved, by default is the environment or name of the selected cluster profile used")
+	flag.Var(&VariableFiles, "var-file", "YAML file defining variables referenced inside topic files through ${var.name}, could be defined multiple times")
+	flag.Var(Variables, "var", "Variable referenced inside topic files through ${var.name} defined as key=value, overrides variable files. Could be defined multiple times")
+	flag.StringVar(&Output, "output", OutputText, "Format of the run report, text, json or yaml. Structured reports are written to stdout and all other output to stderr")
+	flag.StringVar(&JUnitReport, "junit-report", "", "Write every topic definition as test case with its validation, lint, policy and broker problems to the given JUnit XML file")
+	flag.StringVar(&SARIFReport, "sarif-report", "", "Write all validation, lint, policy and broker problems annotated against their file and line to the given SARIF file")
+	flag.StringVar(&ExportGroup, "group-by-prefix", "", "Export command only, groups the topics sharing the prefix before the given separator into a single file")
+
+	flag.CommandLine.Parse(args)
 
-	target, err := filepath.Abs(TargetPath)
+	if command != CommandApply && command != CommandExport && command != CommandDrift {
+		panic(fmt.Errorf(UnknownCommand, command, CommandApply, CommandExport, CommandDrift))
+	}
+
+	err := ValidateOutput(Output)
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf(HeaderReport, Brokers, ValidateMode, StrictMode, target)
+	// The JUnit and SARIF reports are also written when the run fails
+	findings := &Findings{}
+	defer findings.Save(JUnitReport, SARIFReport)
+
+	// Human readable output is written to stderr when a structured report is written to stdout
+	started := time.Now()
+	console := io.Writer(os.Stdout)
+	if Output != OutputText {
+		console = os.Stderr
+	}
+
+	// The default project file is optional, a explicitly defined project file has to exist
+	optional := len(ProjectFile) == 0
+	if optional {
+		ProjectFile = FindProjectFile(DefaultProjectFiles)
+	}
+
+	project, err := LoadProject(ProjectFile, optional)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(ClusterName) == 0 {
+		ClusterName = os.Getenv(ClusterEnv)
+	}
+
+	cluster, err := project.Cluster(ClusterName)
+	if err != nil {
+		panic(err)
+	}
+
+	// Flags override the settings of the cluster profile
+	if len(Brokers) > 0 {
+		cluster.Brokers = strings.Split(Brokers, ",")
+	}
+
+	if len(KafkaVersion) > 0 {
+		cluster.KafkaVersion = KafkaVersion
+	}
+
+	if BatchSize > 0 {
+		cluster.BatchSize = BatchSize
+	}
+
+	cluster.Auth = cluster.Auth.Override(Auth)
+
+	if len(Environment) == 0 {
+		Environment = cluster.Environment
+	}
+
+	if len(Environment) == 0 {
+		Environment = cluster.Name
+	}
+
+	ignore, err := IgnorePatterns(append(project.Ignore, IgnoreTopics...), append(project.Presets, IgnorePreset...))
+	if err != nil {
+		panic(err)
+	}
+
+	if len(TargetPaths) == 0 {
+		TargetPaths = append(TargetPaths, ".")
+	}
+
+	targets := make([]string, len(TargetPaths))
+	for index, path := range TargetPaths {
+		target, err := filepath.Abs(path)
+		if err != nil {
+			panic(err)
+		}
+
+		targets[index] = target
+	}
+
+	// Variables defined through flags override the variable files
+	variables := map[string]string{}
+	for _, path := range VariableFiles {
+		defined, err := LoadVariables(path)
+		if err != nil {
+			panic(err)
+		}
+
+		for key, v := range defined {
+			variables[key] = v
+		}
+	}
+
+	for key, v := range Variables {
+		variables[key] = v
+	}
+
+	// The project and variable files are never scanned as topic configuration files
+	skip := []string{}
+	for _, path := range append([]string{ProjectFile}, VariableFiles...) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			panic(err)
+		}
+
+		skip = append(skip, abs)
+	}
+
+	fmt.Fprintf(console, HeaderReport, cluster, Environment, ValidateMode, StrictMode, PlanMode, targets)
+
+	if command == CommandExport {
+		migration := NewMigration()
+		migration.ManagedPrefixes = append(project.ManagedPrefixes, Managed...)
+		migration.Ignore = ignore
+
+		err = migration.Prepare(cluster)
+		if err != nil {
+			panic(err)
+		}
+
+		files, err := migration.Export(ExportOptions{
+			Directory:      targets[0],
+			GroupSeparator: ExportGroup,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Fprintf(console, ExportReport, len(files), targets[0])
+		return
+	}
+
+	migration := NewMigration()
+	if len(PlanFile) == 0 {
+		migration, err = Scan(targets, ScanOptions{
+			Filter:           Filter{Include: Include, Exclude: Exclude, Skip: skip},
+			StrictExtensions: StrictExtensions,
+			Environment:      Environment,
+			Variables:        variables,
+			Profiles:         project.Profiles,
+		})
+		if errs, ok := err.(ValidationErrors); ok {
+			findings.AddValidation(FindingValidation, errs)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		migration.StrictMode = StrictMode
+	}
+
+	findings.Topics = migration.TopicEntries
+	migration.ManagedPrefixes = append(project.ManagedPrefixes, Managed...)
 
-	migration, err := Scan(target, StrictMode, ValidateMode)
+	err = migration.CheckScope()
 	if err != nil {
 		panic(err)
 	}
 
-	migration.StrictMode = StrictMode
+	if len(PolicyFile) > 0 {
+		policy, err := LoadPolicy(PolicyFile)
+		if err != nil {
+			panic(err)
+		}
+
+		findings.AddPolicy(policy.Evaluate(migration.TopicEntries))
+
+		violations := policy.Enforce(migration.TopicEntries)
+		if violations != nil {
+			panic(violations)
+		}
+	}
+
+	if OfflineMode {
+		version, err := cluster.Version()
+		if err != nil {
+			panic(err)
+		}
+
+		errs := Lint(migration.Entries, version)
+		findings.AddValidation(FindingLint, errs)
+
+		if errs != nil {
+			panic(errs)
+		}
+
+		fmt.Fprintf(console, OfflineReport, version, len(migration.TopicEntries))
+		return
+	}
+
 	migration.ValidateMode = ValidateMode
+	migration.ReassignmentFile = ReassignmentFile
+	migration.Parallelism = Parallelism
+	migration.Ignore = ignore
+	migration.Safeguards = Safeguards{
+		MaxDeletions: MaxDeletions,
+		Protected:    Protected,
+		RecentWrites: RecentWrites,
+		AutoApprove:  AutoApprove,
+	}
 
-	err = migration.Prepare(Brokers, KafkaVersion)
+	err = ValidatePatterns(Protected)
 	if err != nil {
 		panic(err)
 	}
 
-	err = migration.Apply()
+	err = migration.Prepare(cluster)
 	if err != nil {
 		panic(err)
 	}
 
+	if command == CommandDrift {
+		drifts, err := migration.Drift()
+		if err != nil {
+			panic(err)
+		}
+
+		WriteDrift(console, drifts, len(migration.TopicEntries))
+
+		if len(drifts) > 0 {
+			panic(ErrDriftDetected)
+		}
+
+		return
+	}
+
+	var plan *Plan
+
+	switch {
+	case len(PlanFile) > 0:
+		plan, err = LoadPlan(PlanFile)
+		if err != nil {
+			panic(err)
+		}
+
+		err = migration.Verify(plan)
+		if err != nil {
+			panic(err)
+		}
+	default:
+		plan, err = migration.Plan()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	plan.Write(console)
+
+	if len(PlanOut) > 0 {
+		err = plan.Save(PlanOut)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if !ValidateMode {
+		err = migration.Guard(plan)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	report := func(statuses []*EntryStatus) {
+		if Output == OutputText {
+			return
+		}
+
+		report := migration.NewReport(plan, statuses, started)
+		report.Cluster = cluster.String()
+		report.Environment = Environment
+		report.PlanMode = PlanMode
+
+		err := report.Write(os.Stdout, Output)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if PlanMode {
+		report(nil)
+		return
+	}
+
+	if !ValidateMode {
+		err = migration.Confirm(plan, os.Stdin, console)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	statuses, err := migration.Apply(plan)
+	findings.AddStatuses(statuses)
+	report(statuses)
+
+	if err != nil {
+		panic(err)
+	}
+
+	if Output != OutputText {
+		return
+	}
+
 	topics := []string{}
 	entries := []string{}
 	deleted := []string{}
@@ -102,16 +530,25 @@ func main() {
 		entries = append(entries, name)
 	}
 
-	for _, topic := range migration.marked {
-		name := topic.Name
-		if len(name) == 0 {
-			continue
-		}
-
-		if topic.Delete {
-			deleted = append(deleted, name)
+	for _, topic := range plan.Topics {
+		if topic.Action == ActionDelete {
+			deleted = append(deleted, topic.Name)
 		}
 	}
 
-	fmt.Printf(MigrationReport, migration.ValidateMode, migration.StrictMode, Brokers, topics, entries, deleted)
+	fmt.Fprintf(console, MigrationReport, migration.ValidateMode, migration.StrictMode, cluster, topics, entries, deleted)
+}
+
+// StringsFlag represents a flag that could be defined multiple times.
+// Comma separated values are split into multiple values.
+type StringsFlag []string
+
+func (values *StringsFlag) String() string {
+	return strings.Join(*values, ",")
+}
+
+// Set appends the given value(s) to the flag values
+func (values *StringsFlag) Set(value string) error {
+	*values = append(*values, strings.Split(value, ",")...)
+	return nil
 }
@@ -0,0 +1,55 @@
+package main
+
+// Merge merges all overlay entries into their base entry and returns a single entry per topic.
+// Entries are merged in scan order: targets in the order they are defined, files in lexical order
+// inside a target and documents in the order they appear inside a file. A overlay overrides the
+// topic values and configuration properties of the base entry and all previously applied overlays.
+// Values that are not defined inside a overlay are inherited, a configuration property defined
+// without a value inside a overlay removes the inherited property.
+// The given entries are expected to be validated.
+func Merge(entries []Entry) []Entry {
+	result := []Entry{}
+	bases := make(map[string]int, len(entries))
+
+	for _, entry := range entries {
+		if entry.Overlay {
+			continue
+		}
+
+		name := entry.Topic[EntryKeyTopicName]
+		if len(name) > 0 {
+			bases[name] = len(result)
+		}
+
+		result = append(result, entry.copy())
+	}
+
+	for _, overlay := range entries {
+		if !overlay.Overlay {
+			continue
+		}
+
+		index, has := bases[overlay.Topic[EntryKeyTopicName]]
+		if !has {
+			continue
+		}
+
+		base := &result[index]
+		base.Overlays = append(base.Overlays, overlay.Location())
+
+		for key, v := range overlay.Topic {
+			base.Topic[key] = v
+		}
+
+		for key, v := range overlay.Config {
+			if v == nil {
+				delete(base.Config, key)
+				continue
+			}
+
+			base.Config[key] = v
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	entries := []Entry{
+		{
+			Topic:  map[string]string{EntryKeyTopicName: "orders", EntryKeyTopicPartitionSize: "3"},
+			Config: map[string]*string{"retention.ms": ptr("1000"), "flush.messages": ptr("10")},
+			Path:   "base.yaml",
+		},
+		{
+			Overlay:  true,
+			Topic:    map[string]string{EntryKeyTopicName: "orders", EntryKeyTopicPartitionSize: "6"},
+			Config:   map[string]*string{"retention.ms": ptr("2000"), "flush.messages": nil},
+			Path:     "overlay-a.yaml",
+			Document: 1,
+		},
+		{
+			Overlay:  true,
+			Topic:    map[string]string{EntryKeyTopicName: "orders", EntryKeyTopicPartitionSize: "12"},
+			Config:   map[string]*string{"cleanup.policy": ptr("compact")},
+			Path:     "overlay-b.yaml",
+			Document: 1,
+		},
+		{
+			Topic:  map[string]string{EntryKeyTopicName: "payments"},
+			Config: map[string]*string{},
+			Path:   "base.yaml",
+		},
+	}
+
+	merged := Merge(entries)
+	if len(merged) != 2 {
+		t.Fatalf("merged %d entries, expected 2", len(merged))
+	}
+
+	orders := merged[0]
+
+	// The last overlay wins
+	if orders.Topic[EntryKeyTopicPartitionSize] != "12" {
+		t.Errorf("unexpected partitions %q", orders.Topic[EntryKeyTopicPartitionSize])
+	}
+
+	config := map[string]string{}
+	for key, v := range orders.Config {
+		config[key] = value(v)
+	}
+
+	// Properties without a value remove the inherited property
+	expected := map[string]string{"retention.ms": "2000", "cleanup.policy": "compact"}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("unexpected config %v, expected %v", config, expected)
+	}
+
+	overlays := []string{"overlay-a.yaml (document 1)", "overlay-b.yaml (document 1)"}
+	if !reflect.DeepEqual(orders.Overlays, overlays) {
+		t.Errorf("unexpected overlays %v, expected %v", orders.Overlays, overlays)
+	}
+
+	// The base entries are not modified
+	if entries[0].Topic[EntryKeyTopicPartitionSize] != "3" || value(entries[0].Config["flush.messages"]) != "10" {
+		t.Error("the base entry has been modified")
+	}
+
+	if merged[1].Topic[EntryKeyTopicName] != "payments" || len(merged[1].Overlays) != 0 {
+		t.Errorf("unexpected entry %+v", merged[1])
+	}
+}
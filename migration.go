@@ -1,19 +1,16 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"mime"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
-
-	yaml "gopkg.in/yaml.v2"
-
-	"github.com/Shopify/sarama"
+	"time"
 )
 
 // Logging messages
@@ -24,6 +21,82 @@ const (
 	EntryFailed           = "Unable to alter: %s, %s\n"
 	MarkedDeleteFailed    = "Unable to delete the marked topic: %s, %s\n"
 	MarkedDeleteCompleted = "Successfully deleted the marked topic: %s\n"
+	FileIgnored           = "Ignoring the file: %s, the file extension is not recognised\n"
+	FileUnrecognised      = "the file extension of %s is not recognised"
+	ApplyFailed           = "%d of %d planned actions failed"
+	ApplyInvalid          = "%d of %d planned actions are invalid"
+	ApplyFailedAction     = "  %s %s: %s"
+)
+
+// ApplyError represents all failed actions of a applied plan
+type ApplyError struct {
+	// Failed holds the statuses of the failed actions in the order of the plan
+	Failed []*EntryStatus
+	// Applied is the number of actions that have been preformed or validated successfully
+	Applied int
+	// ValidateMode is true if the actions were only validated by the Kafka cluster
+	ValidateMode bool
+}
+
+func (err *ApplyError) Error() string {
+	template := ApplyFailed
+	if err.ValidateMode {
+		template = ApplyInvalid
+	}
+
+	messages := []string{fmt.Sprintf(template, len(err.Failed), len(err.Failed)+err.Applied)}
+	for _, status := range err.Failed {
+		messages = append(messages, fmt.Sprintf(ApplyFailedAction, status.Action, status.Topic.Name, status.Err))
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Disconnected returns true if no action succeeded and all actions failed because
+// the Kafka cluster could not be reached
+func (err *ApplyError) Disconnected() bool {
+	if err.Applied > 0 {
+		return false
+	}
+
+	for _, status := range err.Failed {
+		if !IsConnectionError(status.Err) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewApplyError returns a ApplyError for the failed statuses, nil is returned when no action failed
+func NewApplyError(statuses []*EntryStatus, validate bool) error {
+	errs := &ApplyError{ValidateMode: validate}
+
+	for _, status := range statuses {
+		if status.Err != nil {
+			errs.Failed = append(errs.Failed, status)
+			continue
+		}
+
+		errs.Applied++
+	}
+
+	if len(errs.Failed) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// Entry section keys
+const (
+	EntryKeyTopic   = "topic"
+	EntryKeyConfig  = "config"
+	EntryKeyOverlay = "overlay"
+
+	EntryKeyEnvironment  = "environment"
+	EntryKeyEnvironments = "environments"
+	EntryKeyProfile      = "profile"
 )
 
 // Entry value keys
@@ -33,50 +106,84 @@ const (
 	EntryKeyTopicReplicationSize = "replication"
 )
 
-// Scan scans the given path for topic configuration files and constructs a new migration.
-func Scan(path string, strict, validate bool) (*Migration, error) {
+// Scan recursively scans the given target paths for topic configuration files and constructs a new migration.
+// Only files accepted by the filter of the given options are scanned. Files are decoded with the decoder registered
+// for the mime type of their extension, files with a unrecognised extension are ignored with a warning or rejected
+// when strict extensions are enabled. All ${...} expressions inside the decoded values are rendered.
+// Entries are selected and resolved for the environment of the given options, inherit the values of their
+// profile and have their human readable durations and sizes normalized. All rendering, decoding and
+// validation problems are returned as ValidationErrors.
+func Scan(targets []string, options ScanOptions) (*Migration, error) {
 	migration := NewMigration()
 
-	files, err := ioutil.ReadDir(path)
+	files, err := Discover(targets, options.Filter)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
+	problems := ValidationErrors{}
 
-		name := file.Name()
-		ext := filepath.Ext(name)
+	for _, path := range files {
+		ext := filepath.Ext(path)
 		typ := mime.TypeByExtension(ext)
 
-		file, err := os.Open(filepath.Join(path, name))
+		decoder, has := Decoders[typ]
+		if !has {
+			if options.StrictExtensions {
+				return nil, fmt.Errorf(FileUnrecognised, path)
+			}
+
+			log.Printf(FileIgnored, path)
+			continue
+		}
+
+		source, err := ioutil.ReadFile(path)
 		if err != nil {
 			return nil, err
 		}
 
-		reader := bufio.NewReader(file)
-
-		switch typ {
-		case TypeYAML:
-			dec := yaml.NewDecoder(reader)
+		entries, err := decoder(bytes.NewReader(source))
+		if err != nil {
+			problems = append(problems, ValidationError{
+				Path:    path,
+				Message: err.Error(),
+			})
+		}
 
-			for {
-				entry := Entry{}
-				err := dec.Decode(&entry)
-				if err != nil {
-					break
-				}
+		for index := range entries {
+			entries[index].Path = path
+		}
 
-				migration.Entries = append(migration.Entries, entry)
-			}
+		errs := Render(entries, options.Variables)
+		if errs != nil {
+			problems = append(problems, errs...)
+			continue
 		}
 
-		file.Close()
+		migration.Entries = append(migration.Entries, entries...)
 	}
 
-	for _, entry := range migration.Entries {
+	migration.Entries = Select(migration.Entries, options.Environment)
+
+	problems = append(problems, Validate(migration.Entries)...)
+	problems = append(problems, ValidateProfiles(migration.Entries, options.Profiles)...)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	for index, entry := range migration.Entries {
+		normalized, errs := entry.Resolve(options.Environment).Inherit(options.Profiles).Normalize()
+		problems = append(problems, errs...)
+		migration.Entries[index] = normalized
+	}
+
+	// Environment and profile values are only known once resolved and inherited
+	problems = append(problems, Validate(migration.Entries)...)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	for _, entry := range Merge(migration.Entries) {
 		// Ignore empty entries
 		if len(entry.Topic) == 0 {
 			continue
@@ -90,14 +197,26 @@ func Scan(path string, strict, validate bool) (*Migration, error) {
 			entry.Topic[EntryKeyTopicReplicationSize] = "0"
 		}
 
+		report := func(key string) {
+			problems = append(problems, ValidationError{
+				Path:     entry.Path,
+				Document: entry.Document,
+				Line:     entry.LineOf(EntryKeyTopic + "." + key),
+				Message:  fmt.Sprintf(ValidationNotNumeric, key, entry.Topic[key]),
+				Topic:    entry.Topic[EntryKeyTopicName],
+			})
+		}
+
 		name := entry.Topic[EntryKeyTopicName]
 		partitions, err := strconv.ParseInt(entry.Topic[EntryKeyTopicPartitionSize], 0, 32)
 		if err != nil {
+			report(EntryKeyTopicPartitionSize)
 			continue
 		}
 
 		replications, err := strconv.ParseInt(entry.Topic[EntryKeyTopicReplicationSize], 0, 16)
 		if err != nil {
+			report(EntryKeyTopicReplicationSize)
 			continue
 		}
 
@@ -108,6 +227,9 @@ func Scan(path string, strict, validate bool) (*Migration, error) {
 		topic := Topic{
 			Name:          name,
 			ConfigEntries: entry.Config,
+			Source:        entry.Source(),
+			Path:          entry.Path,
+			Line:          entry.Line,
 		}
 
 		if partitions > 0 {
@@ -121,6 +243,10 @@ func Scan(path string, strict, validate bool) (*Migration, error) {
 		migration.TopicEntries[topic.Name] = topic
 	}
 
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
 	return migration, nil
 }
 
@@ -143,20 +269,25 @@ type Migration struct {
 	ValidateMode bool
 	StrictMode   bool
 
+	// ReassignmentFile is the path to which replica reassignments are written instead of being submitted
+	ReassignmentFile string
+	// Safeguards protect topics from being deleted in strict mode
+	Safeguards Safeguards
+	// Ignore holds glob or /regex/ patterns of unmanaged topics that are never marked for deletion
+	Ignore []string
+	// ManagedPrefixes holds the topic name prefixes managed by the migration, all topics are managed when empty
+	ManagedPrefixes []string
+	// Parallelism is the number of topics that are updated concurrently, at least one
+	Parallelism int
+
 	mutex  sync.RWMutex
 	client *KafkaAdmin
 	marked map[string]Topic
 }
 
 // Prepare prepares the migration to preform actions on the Kafka cluster
-func (migration *Migration) Prepare(brokers, version string) error {
-	b := strings.Split(brokers, ",")
-	v, err := sarama.ParseKafkaVersion(version)
-	if err != nil {
-		return err
-	}
-
-	client, err := NewKafkaAdmin(b, v)
+func (migration *Migration) Prepare(cluster Cluster) error {
+	client, err := NewKafkaAdmin(cluster)
 	if err != nil {
 		return err
 	}
@@ -166,6 +297,9 @@ func (migration *Migration) Prepare(brokers, version string) error {
 		return err
 	}
 
+	migration.mutex.Lock()
+	defer migration.mutex.Unlock()
+
 	if migration.StrictMode {
 		for _, topic := range topics {
 			_, has := migration.TopicEntries[topic.Name]
@@ -178,60 +312,87 @@ func (migration *Migration) Prepare(brokers, version string) error {
 				continue
 			}
 
+			// Topics outside the managed scope are owned by others
+			if !migration.Manages(topic.Name) {
+				continue
+			}
+
+			if MatchPatterns(migration.Ignore, topic.Name) {
+				log.Printf(TopicIgnored, topic.Name)
+				continue
+			}
+
 			topic.Delete = true
 			migration.marked[topic.Name] = topic
 			log.Printf(TopicMarkedForDeletion, topic.Name)
 		}
 	}
 
-	migration.mutex.RLock()
-	defer migration.mutex.RUnlock()
-
 	migration.Topics = topics
 	migration.client = client
 
 	return nil
 }
 
-// Apply applies the set entries to the defined Kafka topics
-func (migration *Migration) Apply() error {
-	results := make([]*EntryStatus, len(migration.Entries))
+// Apply applies the given plan to the defined Kafka topics and returns the status of every preformed action.
+// In validate mode are all planned actions validated by the Kafka cluster but not preformed. A failed action
+// does not stop the remaining actions, all failed actions are returned as ApplyError. New topics are
+// validated and created in batches, topics are updated concurrently by the configured number of workers
+// and deletions are preformed one at a time. The returned statuses are ordered as the plan regardless
+// of the order in which the actions completed.
+func (migration *Migration) Apply(plan *Plan) ([]*EntryStatus, error) {
+	// Replication changes are refused before any action is preformed
+	err := migration.CheckReassignments(plan)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, topic := range migration.TopicEntries {
-		status := &EntryStatus{
-			Topic: topic,
-		}
+	workers := migration.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
 
-		results = append(results, status)
+	completed := make([]*EntryStatus, len(plan.Topics))
+	queue := make(chan int)
+	group := sync.WaitGroup{}
 
-		_, exists := migration.Topics[topic.Name]
-		if !exists {
-			migration.client.CreateTopic(topic)
+	created := migration.create(plan)
+	for index, planned := range plan.Topics {
+		if status, has := created[planned.Name]; has {
+			completed[index] = status
 		}
+	}
 
-		if migration.ValidateMode {
-			err := migration.client.ValidateConfiguration(topic, topic.ConfigEntries, migration.StrictMode)
-			if err != nil {
-				status.Err = err
-				continue
-			}
+	for worker := 0; worker < workers; worker++ {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
 
-			status.Success = true
+			for index := range queue {
+				status := migration.apply(plan.Topics[index])
 
-			if !exists {
-				migration.client.DeleteTopic(topic)
+				migration.mutex.Lock()
+				completed[index] = status
+				migration.mutex.Unlock()
 			}
+		}()
+	}
 
-			continue
+	for index, planned := range plan.Topics {
+		if planned.Action == ActionUpdate {
+			queue <- index
 		}
+	}
 
-		err := migration.client.AlterConfiguration(topic, topic.ConfigEntries, migration.StrictMode)
-		if err != nil {
-			status.Err = err
-			continue
-		}
+	close(queue)
+	group.Wait()
 
-		status.Success = true
+	results := make([]*EntryStatus, 0, len(plan.Topics))
+	for _, status := range completed {
+		if status != nil {
+			results = append(results, status)
+		}
 	}
 
 results:
@@ -259,19 +420,157 @@ results:
 	}
 
 	if !migration.ValidateMode {
-		// Checking marked topics
-		for _, topic := range migration.marked {
+		// Checking topics planned for deletion
+		for _, planned := range plan.Topics {
+			topic := planned.Topic()
+
 			switch {
 			case topic.Delete:
+				started := time.Now()
 				err := migration.client.DeleteTopic(topic)
+
+				results = append(results, &EntryStatus{
+					Success:  err == nil,
+					Err:      err,
+					Topic:    topic,
+					Action:   planned.Action,
+					Duration: time.Since(started),
+				})
+
 				if err != nil {
 					log.Printf(MarkedDeleteFailed, topic.Name, err)
+					continue
 				}
 
 				log.Printf(MarkedDeleteCompleted, topic.Name)
 			}
 		}
+
+		reassignment := NewReassignment(plan)
+
+		switch {
+		case len(reassignment.Partitions) == 0:
+		case len(migration.ReassignmentFile) > 0:
+			err := reassignment.Save(migration.ReassignmentFile)
+			if err != nil {
+				return results, err
+			}
+
+			log.Printf(ReassignmentWritten, reassignment.Topics(), migration.ReassignmentFile, migration.ReassignmentFile)
+		default:
+			err := migration.AwaitReassignments(reassignment)
+			if err != nil {
+				return results, err
+			}
+		}
 	}
 
-	return nil
+	return results, NewApplyError(results, migration.ValidateMode)
+}
+
+// create validates all planned topic creations and creates the valid topics in batches,
+// in validate mode the topics are only validated. The statuses are returned by topic name.
+func (migration *Migration) create(plan *Plan) map[string]*EntryStatus {
+	topics := []Topic{}
+	for _, planned := range plan.Topics {
+		if planned.Action == ActionCreate {
+			topics = append(topics, planned.Topic())
+		}
+	}
+
+	statuses := make(map[string]*EntryStatus, len(topics))
+	if len(topics) == 0 {
+		return statuses
+	}
+
+	started := time.Now()
+	errs := migration.client.CreateTopics(topics, true)
+
+	if !migration.ValidateMode {
+		valid := make([]Topic, 0, len(topics))
+		for _, topic := range topics {
+			if errs[topic.Name] == nil {
+				valid = append(valid, topic)
+			}
+		}
+
+		for name, err := range migration.client.CreateTopics(valid, false) {
+			errs[name] = err
+		}
+	}
+
+	// The topics are validated and created together and share the duration
+	duration := time.Since(started)
+
+	for _, topic := range topics {
+		statuses[topic.Name] = &EntryStatus{
+			Success:  errs[topic.Name] == nil,
+			Err:      errs[topic.Name],
+			Topic:    topic,
+			Action:   ActionCreate,
+			Duration: duration,
+		}
+	}
+
+	return statuses
+}
+
+// apply preforms or validates the planned update of a single topic and returns its status
+func (migration *Migration) apply(planned TopicPlan) *EntryStatus {
+	topic := planned.Topic()
+	status := &EntryStatus{
+		Topic:  topic,
+		Action: planned.Action,
+	}
+
+	started := time.Now()
+	err := migration.update(planned)
+	status.Duration = time.Since(started)
+
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	status.Success = true
+	return status
+}
+
+// update preforms the planned update of a existing topic. Partitions are increased before the topic
+// configuration is altered, replica reassignments are submitted last unless a reassignment file is defined.
+func (migration *Migration) update(planned TopicPlan) error {
+	topic := planned.Topic()
+
+	if planned.IncreasesPartitions() {
+		var err error
+		if migration.ValidateMode {
+			err = migration.client.ValidatePartitions(topic)
+		} else {
+			err = migration.client.CreatePartitions(topic)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(planned.Changes) > 0 {
+		var err error
+		if migration.ValidateMode {
+			err = migration.client.ValidateConfiguration(topic, planned.Config)
+		} else {
+			err = migration.client.AlterConfiguration(topic, planned.Config)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Reassignments could not be validated by the Kafka cluster
+	if !planned.ChangesReplication() || migration.ValidateMode || len(migration.ReassignmentFile) > 0 {
+		return nil
+	}
+
+	return migration.client.ReassignPartitions(topic, planned.Assignment)
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchPattern returns true if the given topic name matches the given pattern.
+// Patterns wrapped in slashes (/^orders\..*$/) are matched as regular expressions,
+// all other patterns are matched as glob patterns.
+func MatchPattern(pattern, name string) bool {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expr, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false
+		}
+
+		return expr.MatchString(name)
+	}
+
+	return MatchGlob(pattern, name)
+}
+
+// MatchPatterns returns true if the given topic name matches any of the given patterns
+func MatchPatterns(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if MatchPattern(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidatePatterns checks whether all regular expression patterns inside the given patterns compile
+func ValidatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if len(pattern) < 2 || !strings.HasPrefix(pattern, "/") || !strings.HasSuffix(pattern, "/") {
+			continue
+		}
+
+		_, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PatternsFlag represents a glob or /regex/ pattern flag that could be defined multiple times.
+// Values are never split on commas as commas are part of regular expressions such as {1,3}.
+type PatternsFlag []string
+
+func (patterns *PatternsFlag) String() string {
+	return strings.Join(*patterns, " ")
+}
+
+// Set appends the given pattern to the flag values
+func (patterns *PatternsFlag) Set(pattern string) error {
+	*patterns = append(*patterns, pattern)
+	return nil
+}
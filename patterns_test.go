@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"_confluent-*", "_confluent-metrics", true},
+		{"_confluent-*", "confluent-metrics", false},
+		{"*-changelog", "app-store-changelog", true},
+		{"/^orders\\..*$/", "orders.created", true},
+		{"/^orders\\..*$/", "payments.orders.created", false},
+		{"/[/", "[", false},
+		{"/", "/", true},
+	}
+
+	for _, test := range tests {
+		got := MatchPattern(test.pattern, test.name)
+		if got != test.want {
+			t.Errorf("MatchPattern(%q, %q) = %t, expected %t", test.pattern, test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	if err := ValidatePatterns([]string{"orders-*", "/^orders$/"}); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if err := ValidatePatterns([]string{"/[/"}); err == nil {
+		t.Error("expected a error for a invalid regular expression")
+	}
+}
+
+func TestPatternsFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		matches string
+	}{
+		{
+			name:    "repeated",
+			args:    []string{"-protected", "orders-*", "-protected", "payments-*"},
+			want:    []string{"orders-*", "payments-*"},
+			matches: "orders-v1",
+		},
+		{
+			name:    "regex with a repetition range",
+			args:    []string{"-protected", `/^orders\.v{1,3}$/`},
+			want:    []string{`/^orders\.v{1,3}$/`},
+			matches: "orders.vv",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patterns := PatternsFlag{}
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.Var(&patterns, "protected", "")
+
+			err := flags.Parse(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual([]string(patterns), test.want) {
+				t.Errorf("unexpected patterns %q, expected %q", patterns, test.want)
+			}
+
+			err = ValidatePatterns(patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !MatchPatterns(patterns, test.matches) {
+				t.Errorf("the patterns should match %s", test.matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"sort"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Planned topic actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionNone   = "no-op"
+)
+
+// Plan reporting templates
+const (
+	PlanTopicCreate   = "  + %s (partitions: %d, replication: %d)\n"
+	PlanTopicUpdate   = "  ~ %s\n"
+	PlanTopicDelete   = "  - %s\n"
+	PlanTopicSource   = "      # %s\n"
+	PlanConfigAdd     = "      + %s: %s\n"
+	PlanConfigModify  = "      ~ %s: %s → %s\n"
+	PlanConfigRemove  = "      - %s: %s\n"
+	PlanPartitions    = "      ~ partitions: %d → %d\n"
+	PlanReplication   = "      ~ replication: %d → %d\n"
+	PlanSummary       = "Plan: %d to create, %d to update, %d to delete, %d unchanged.\n"
+	PlanNoChanges     = "No changes. The Kafka cluster matches the topic configuration entries.\n"
+	PlanStaleTopic    = "the plan is stale: the topic %s has been modified since the plan was created"
+	PlanUnknownAction = "unknown plan action %q for the topic %s"
+	PlanShrinkTopic   = "the topic %s (%s) defines %d partitions but has %d partitions, Kafka is unable to reduce the number of partitions"
+)
+
+// ConfigChange represents a single configuration property change.
+// A nil Old value represents a newly added property and a nil
+// New value represents a removed property.
+type ConfigChange struct {
+	Key string  `yaml:"key"`
+	Old *string `yaml:"old,omitempty"`
+	New *string `yaml:"new,omitempty"`
+}
+
+// TopicPlan represents the planned action for a single Kafka topic
+type TopicPlan struct {
+	Name               string             `yaml:"name"`
+	Action             string             `yaml:"action"`
+	Source             string             `yaml:"source,omitempty"`
+	CurrentPartitions  int32              `yaml:"current_partitions,omitempty"`
+	NumPartitions      int32              `yaml:"partitions,omitempty"`
+	CurrentReplication int16              `yaml:"current_replication,omitempty"`
+	ReplicationFactor  int16              `yaml:"replication,omitempty"`
+	Assignment         map[int32][]int32  `yaml:"assignment,omitempty"`
+	Changes            []ConfigChange     `yaml:"changes,omitempty"`
+	Config             map[string]*string `yaml:"config,omitempty"`
+	// CurrentConfig is the snapshot of all explicitly set configuration properties of a existing topic
+	// taken when the plan was created, a saved plan is refused when the snapshot no longer matches
+	CurrentConfig map[string]*string `yaml:"current_config,omitempty"`
+}
+
+// Topic returns the topic that is targeted by the given topic plan
+func (plan TopicPlan) Topic() Topic {
+	return Topic{
+		Name:              plan.Name,
+		ConfigEntries:     plan.Config,
+		NumPartitions:     plan.NumPartitions,
+		ReplicationFactor: plan.ReplicationFactor,
+		Delete:            plan.Action == ActionDelete,
+		Source:            plan.Source,
+	}
+}
+
+// Plan represents all actions that have to be preformed to migrate
+// the Kafka cluster towards the defined topic configuration entries.
+type Plan struct {
+	StrictMode bool        `yaml:"strict"`
+	Topics     []TopicPlan `yaml:"topics"`
+}
+
+// IncreasesPartitions returns true if the plan increases the number of partitions of a existing topic
+func (plan TopicPlan) IncreasesPartitions() bool {
+	return plan.Action == ActionUpdate && plan.NumPartitions > plan.CurrentPartitions
+}
+
+// ChangesReplication returns true if the plan changes the replication factor of a existing topic
+func (plan TopicPlan) ChangesReplication() bool {
+	return plan.Action == ActionUpdate && plan.ReplicationFactor > 0 && plan.ReplicationFactor != plan.CurrentReplication
+}
+
+// HasChanges returns true if the plan contains any action besides no-op
+func (plan *Plan) HasChanges() bool {
+	for _, topic := range plan.Topics {
+		if topic.Action != ActionNone {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Deletions returns the names of all topics that are planned for deletion
+func (plan *Plan) Deletions() []string {
+	deletions := []string{}
+	for _, topic := range plan.Topics {
+		if topic.Action == ActionDelete {
+			deletions = append(deletions, topic.Name)
+		}
+	}
+
+	return deletions
+}
+
+// Write writes a human readable diff of the plan to the given writer
+func (plan *Plan) Write(w io.Writer) {
+	counts := make(map[string]int, 4)
+
+	for _, topic := range plan.Topics {
+		counts[topic.Action]++
+
+		switch topic.Action {
+		case ActionCreate:
+			fmt.Fprintf(w, PlanTopicCreate, topic.Name, topic.NumPartitions, topic.ReplicationFactor)
+		case ActionUpdate:
+			fmt.Fprintf(w, PlanTopicUpdate, topic.Name)
+
+			if topic.IncreasesPartitions() {
+				fmt.Fprintf(w, PlanPartitions, topic.CurrentPartitions, topic.NumPartitions)
+			}
+
+			if topic.ChangesReplication() {
+				fmt.Fprintf(w, PlanReplication, topic.CurrentReplication, topic.ReplicationFactor)
+			}
+		case ActionDelete:
+			fmt.Fprintf(w, PlanTopicDelete, topic.Name)
+		default:
+			continue
+		}
+
+		if len(topic.Source) > 0 {
+			fmt.Fprintf(w, PlanTopicSource, topic.Source)
+		}
+
+		for _, change := range topic.Changes {
+			switch {
+			case change.Old == nil:
+				fmt.Fprintf(w, PlanConfigAdd, change.Key, Humanize(change.Key, value(change.New)))
+			case change.New == nil:
+				fmt.Fprintf(w, PlanConfigRemove, change.Key, Humanize(change.Key, value(change.Old)))
+			default:
+				fmt.Fprintf(w, PlanConfigModify, change.Key, Humanize(change.Key, value(change.Old)), Humanize(change.Key, value(change.New)))
+			}
+		}
+	}
+
+	if !plan.HasChanges() {
+		fmt.Fprint(w, PlanNoChanges)
+		return
+	}
+
+	fmt.Fprintf(w, PlanSummary, counts[ActionCreate], counts[ActionUpdate], counts[ActionDelete], counts[ActionNone])
+}
+
+// Save writes the plan to the given file path
+func (plan *Plan) Save(path string) error {
+	bb, err := yaml.Marshal(plan)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, bb, 0644)
+}
+
+// LoadPlan reads a previously saved plan from the given file path
+func LoadPlan(path string) (*Plan, error) {
+	bb, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	err = yaml.Unmarshal(bb, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// Plan computes the actions required to migrate the Kafka cluster towards the topic entries.
+// The migration has to be prepared before a plan could be constructed.
+func (migration *Migration) Plan() (*Plan, error) {
+	plan := &Plan{
+		StrictMode: migration.StrictMode,
+	}
+
+	// Available brokers are only fetched when a replica assignment has to be constructed
+	var brokers []int32
+
+	existing := []string{}
+	for _, name := range sortedTopicNames(migration.TopicEntries) {
+		if _, exists := migration.Topics[name]; exists {
+			existing = append(existing, name)
+		}
+	}
+
+	configs, err := migration.client.DescribeConfigurations(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, topic := range migration.TopicEntries {
+		live, exists := migration.Topics[topic.Name]
+		if !exists {
+			plan.Topics = append(plan.Topics, TopicPlan{
+				Name:              topic.Name,
+				Action:            ActionCreate,
+				Source:            topic.Source,
+				NumPartitions:     topic.NumPartitions,
+				ReplicationFactor: topic.ReplicationFactor,
+				Changes:           DiffConfiguration(nil, topic.ConfigEntries),
+				Config:            topic.ConfigEntries,
+			})
+
+			continue
+		}
+
+		current := configs[topic.Name]
+		config := EnforceConfiguration(topic, current, topic.ConfigEntries, migration.StrictMode)
+		changes := DiffConfiguration(current, config)
+
+		planned := TopicPlan{
+			Name:          topic.Name,
+			Action:        ActionNone,
+			Source:        topic.Source,
+			Changes:       changes,
+			Config:        config,
+			CurrentConfig: current,
+		}
+
+		if topic.NumPartitions > 0 && topic.NumPartitions < live.NumPartitions {
+			return nil, fmt.Errorf(PlanShrinkTopic, topic.Name, topic.Source, topic.NumPartitions, live.NumPartitions)
+		}
+
+		if topic.NumPartitions > live.NumPartitions {
+			planned.CurrentPartitions = live.NumPartitions
+			planned.NumPartitions = topic.NumPartitions
+		}
+
+		if topic.ReplicationFactor > 0 && topic.ReplicationFactor != live.ReplicationFactor {
+			if brokers == nil {
+				brokers, err = migration.client.DescribeBrokers()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			partitions := live.NumPartitions
+			if planned.NumPartitions > partitions {
+				partitions = planned.NumPartitions
+			}
+
+			planned.CurrentReplication = live.ReplicationFactor
+			planned.ReplicationFactor = topic.ReplicationFactor
+			planned.Assignment, err = AssignReplicas(topic.Name, live.ReplicaAssignment, partitions, topic.ReplicationFactor, brokers)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(changes) > 0 || planned.NumPartitions > 0 || planned.ReplicationFactor > 0 {
+			planned.Action = ActionUpdate
+		}
+
+		plan.Topics = append(plan.Topics, planned)
+	}
+
+	for _, topic := range migration.marked {
+		if !topic.Delete {
+			continue
+		}
+
+		plan.Topics = append(plan.Topics, TopicPlan{
+			Name:   topic.Name,
+			Action: ActionDelete,
+		})
+	}
+
+	sort.Slice(plan.Topics, func(i, j int) bool {
+		return plan.Topics[i].Name < plan.Topics[j].Name
+	})
+
+	return plan, nil
+}
+
+// Verify checks whether the given plan still matches the current state of the Kafka cluster.
+// A error is returned if the cluster has been modified since the plan was created
+// or when the plan modifies topics outside the managed scope.
+func (migration *Migration) Verify(plan *Plan) error {
+	updated := []string{}
+	for _, topic := range plan.Topics {
+		if _, exists := migration.Topics[topic.Name]; exists && topic.Action == ActionUpdate {
+			updated = append(updated, topic.Name)
+		}
+	}
+
+	err := migration.CheckReassignments(plan)
+	if err != nil {
+		return err
+	}
+
+	configs, err := migration.client.DescribeConfigurations(updated)
+	if err != nil {
+		return err
+	}
+
+	for _, topic := range plan.Topics {
+		live, exists := migration.Topics[topic.Name]
+
+		if topic.Action != ActionNone && !migration.Manages(topic.Name) {
+			return fmt.Errorf(ScopeOutside, topic.Name, topic.Source, migration.ManagedPrefixes)
+		}
+
+		switch topic.Action {
+		case ActionCreate:
+			if exists {
+				return fmt.Errorf(PlanStaleTopic, topic.Name)
+			}
+		case ActionDelete, ActionNone:
+			if !exists {
+				return fmt.Errorf(PlanStaleTopic, topic.Name)
+			}
+		case ActionUpdate:
+			if !exists {
+				return fmt.Errorf(PlanStaleTopic, topic.Name)
+			}
+
+			if topic.IncreasesPartitions() && live.NumPartitions != topic.CurrentPartitions {
+				return fmt.Errorf(PlanStaleTopic, topic.Name)
+			}
+
+			if topic.ChangesReplication() && live.ReplicationFactor != topic.CurrentReplication {
+				return fmt.Errorf(PlanStaleTopic, topic.Name)
+			}
+
+			// The complete configuration is altered, any property modified since the plan was created would be reverted
+			if len(DiffConfiguration(topic.CurrentConfig, configs[topic.Name])) > 0 {
+				return fmt.Errorf(PlanStaleTopic, topic.Name)
+			}
+		default:
+			return fmt.Errorf(PlanUnknownAction, topic.Action, topic.Name)
+		}
+	}
+
+	return nil
+}
+
+// EnforceConfiguration constructs the configuration that should be applied to the given topic.
+// Properties that are explicitly set on the topic but not defined in the given configuration are
+// preserved unless strict mode is enabled.
+func EnforceConfiguration(topic Topic, current, config map[string]*string, strict bool) map[string]*string {
+	result := make(map[string]*string, len(config))
+
+	for key, v := range config {
+		result[key] = v
+	}
+
+	for key, v := range current {
+		_, has := config[key]
+		if has {
+			continue
+		}
+
+		log.Printf(EntryPropertyNotDefined, key, topic.Name)
+
+		if strict {
+			log.Printf(EntryPropertyDeleted, key, topic.Name)
+			continue
+		}
+
+		result[key] = v
+	}
+
+	return result
+}
+
+// DiffConfiguration returns the configuration changes required to move from the current to the
+// desired configuration. The returned changes are sorted by key.
+func DiffConfiguration(current, desired map[string]*string) []ConfigChange {
+	changes := []ConfigChange{}
+
+	for key, v := range desired {
+		previous, has := current[key]
+		if has && equal(previous, v) {
+			continue
+		}
+
+		change := ConfigChange{
+			Key: key,
+			New: v,
+		}
+
+		if has {
+			change.Old = previous
+		}
+
+		// Represent properties without a value as empty strings to distinguish them from removals
+		if change.New == nil {
+			empty := ""
+			change.New = &empty
+		}
+
+		changes = append(changes, change)
+	}
+
+	for key, v := range current {
+		_, has := desired[key]
+		if has {
+			continue
+		}
+
+		changes = append(changes, ConfigChange{
+			Key: key,
+			Old: v,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// equal returns true if both given values represent the same configuration value
+func equal(a, b *string) bool {
+	return value(a) == value(b)
+}
+
+// value returns the string representation of the given configuration value
+func value(v *string) string {
+	if v == nil {
+		return ""
+	}
+
+	return *v
+}
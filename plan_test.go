@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func ptr(v string) *string {
+	return &v
+}
+
+func TestDiffConfiguration(t *testing.T) {
+	tests := []struct {
+		name    string
+		current map[string]*string
+		desired map[string]*string
+		want    []ConfigChange
+	}{
+		{
+			name:    "equal",
+			current: map[string]*string{"retention.ms": ptr("1000")},
+			desired: map[string]*string{"retention.ms": ptr("1000")},
+			want:    []ConfigChange{},
+		},
+		{
+			name:    "added",
+			current: nil,
+			desired: map[string]*string{"retention.ms": ptr("1000")},
+			want:    []ConfigChange{{Key: "retention.ms", New: ptr("1000")}},
+		},
+		{
+			name:    "modified",
+			current: map[string]*string{"retention.ms": ptr("1000")},
+			desired: map[string]*string{"retention.ms": ptr("2000")},
+			want:    []ConfigChange{{Key: "retention.ms", Old: ptr("1000"), New: ptr("2000")}},
+		},
+		{
+			name:    "removed",
+			current: map[string]*string{"retention.ms": ptr("1000")},
+			desired: map[string]*string{},
+			want:    []ConfigChange{{Key: "retention.ms", Old: ptr("1000")}},
+		},
+		{
+			name:    "without value",
+			current: map[string]*string{},
+			desired: map[string]*string{"flush.messages": nil},
+			want:    []ConfigChange{{Key: "flush.messages", New: ptr("")}},
+		},
+		{
+			name:    "sorted by key",
+			current: map[string]*string{"segment.ms": ptr("1"), "cleanup.policy": ptr("delete")},
+			desired: map[string]*string{"retention.ms": ptr("1"), "cleanup.policy": ptr("compact")},
+			want: []ConfigChange{
+				{Key: "cleanup.policy", Old: ptr("delete"), New: ptr("compact")},
+				{Key: "retention.ms", New: ptr("1")},
+				{Key: "segment.ms", Old: ptr("1")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := DiffConfiguration(test.current, test.desired)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected changes %+v, expected %+v", got, test.want)
+			}
+		})
+	}
+}
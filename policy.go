@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Policy rule severities
+const (
+	SeverityWarn  = "warn"
+	SeverityError = "error"
+)
+
+// Policy messages
+const (
+	PolicyUnknownSeverity    = "unknown severity %q for the policy rule %s, expected %s or %s"
+	PolicyInvalidPattern     = "invalid name pattern for the policy rule %s: %s"
+	PolicyMinReplication     = "the replication factor %d is lower than the minimum of %d"
+	PolicyUndefinedReplicas  = "the replication factor is not defined, a minimum of %d is required"
+	PolicyMaxPartitions      = "the number of partitions %d exceeds the maximum of %d"
+	PolicyNamePattern        = "the topic name does not match the pattern %s"
+	PolicyRequiredConfig     = "the configuration property %s is required"
+	PolicyForbiddenConfig    = "the configuration property %s is not allowed to be set to %q"
+	PolicyInvalidForbidden   = "invalid forbidden value for the policy rule %s: %s"
+	PolicyViolationWarning   = "Policy warning: %s\n"
+	PolicyViolationError     = "%s: the topic %s (%s) violates the policy rule %s: %s"
+	PolicyViolationsDetected = "%d policy violations with severity error detected"
+	PolicyDecodeFailed       = "unable to decode the policy file %s: %s"
+)
+
+// Policy represents a collection of organisational rules topic definitions have to comply with
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule represents a single organisational rule. A rule could define multiple
+// checks, topics matching one of the exempt glob patterns are not checked.
+type PolicyRule struct {
+	Name            string            `yaml:"name"`
+	Severity        string            `yaml:"severity"`
+	Exempt          []string          `yaml:"exempt"`
+	MinReplication  int16             `yaml:"min_replication"`
+	MaxPartitions   int32             `yaml:"max_partitions"`
+	NamePattern     string            `yaml:"name_pattern"`
+	RequiredConfig  []string          `yaml:"required_config"`
+	ForbiddenConfig map[string]string `yaml:"forbidden_config"`
+
+	pattern *regexp.Regexp
+}
+
+// PolicyViolation represents a topic that does not comply with a policy rule
+type PolicyViolation struct {
+	Rule     string
+	Severity string
+	Topic    string
+	Source   string
+	Path     string
+	Line     int
+	Message  string
+}
+
+func (violation PolicyViolation) Error() string {
+	return fmt.Sprintf(PolicyViolationError, violation.Severity, violation.Topic, violation.Source, violation.Rule, violation.Message)
+}
+
+// PolicyViolations represents a collection of policy violations
+type PolicyViolations []PolicyViolation
+
+func (violations PolicyViolations) Error() string {
+	messages := make([]string, len(violations))
+	for index, violation := range violations {
+		messages[index] = violation.Error()
+	}
+
+	messages = append(messages, fmt.Sprintf(PolicyViolationsDetected, len(violations)))
+	return strings.Join(messages, "\n")
+}
+
+// LoadPolicy reads and validates the policy file at the given path
+func LoadPolicy(path string) (*Policy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	policy := &Policy{}
+	dec := yaml.NewDecoder(file)
+	dec.KnownFields(true)
+
+	err = dec.Decode(policy)
+	if err != nil {
+		return nil, fmt.Errorf(PolicyDecodeFailed, path, err)
+	}
+
+	for index := range policy.Rules {
+		rule := &policy.Rules[index]
+
+		if len(rule.Severity) == 0 {
+			rule.Severity = SeverityError
+		}
+
+		if rule.Severity != SeverityWarn && rule.Severity != SeverityError {
+			return nil, fmt.Errorf(PolicyUnknownSeverity, rule.Severity, rule.Name, SeverityWarn, SeverityError)
+		}
+
+		// Forbidden values are compared against the normalized configuration properties of topics
+		for key, forbidden := range rule.ForbiddenConfig {
+			rule.ForbiddenConfig[key], err = Normalize(key, forbidden)
+			if err != nil {
+				return nil, fmt.Errorf(PolicyInvalidForbidden, rule.Name, err)
+			}
+		}
+
+		if len(rule.NamePattern) == 0 {
+			continue
+		}
+
+		rule.pattern, err = regexp.Compile(rule.NamePattern)
+		if err != nil {
+			return nil, fmt.Errorf(PolicyInvalidPattern, rule.Name, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// Evaluate checks the given topics against all policy rules and returns the found violations
+// ordered by topic name and rule definition order.
+func (policy *Policy) Evaluate(topics map[string]Topic) []PolicyViolation {
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	violations := []PolicyViolation{}
+
+	for _, name := range names {
+		topic := topics[name]
+
+		for _, rule := range policy.Rules {
+			if MatchAny(rule.Exempt, topic.Name) {
+				continue
+			}
+
+			for _, message := range rule.Check(topic) {
+				violations = append(violations, PolicyViolation{
+					Rule:     rule.Name,
+					Severity: rule.Severity,
+					Topic:    topic.Name,
+					Source:   topic.Source,
+					Path:     topic.Path,
+					Line:     topic.Line,
+					Message:  message,
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// Check checks the given topic against the rule and returns a message for every failed check
+func (rule PolicyRule) Check(topic Topic) []string {
+	messages := []string{}
+
+	if rule.MinReplication > 0 {
+		switch {
+		case topic.ReplicationFactor == 0:
+			messages = append(messages, fmt.Sprintf(PolicyUndefinedReplicas, rule.MinReplication))
+		case topic.ReplicationFactor < rule.MinReplication:
+			messages = append(messages, fmt.Sprintf(PolicyMinReplication, topic.ReplicationFactor, rule.MinReplication))
+		}
+	}
+
+	if rule.MaxPartitions > 0 && topic.NumPartitions > rule.MaxPartitions {
+		messages = append(messages, fmt.Sprintf(PolicyMaxPartitions, topic.NumPartitions, rule.MaxPartitions))
+	}
+
+	if rule.pattern != nil && !rule.pattern.MatchString(topic.Name) {
+		messages = append(messages, fmt.Sprintf(PolicyNamePattern, rule.NamePattern))
+	}
+
+	for _, key := range rule.RequiredConfig {
+		_, has := topic.ConfigEntries[key]
+		if !has {
+			messages = append(messages, fmt.Sprintf(PolicyRequiredConfig, key))
+		}
+	}
+
+	forbidden := make([]string, 0, len(rule.ForbiddenConfig))
+	for key := range rule.ForbiddenConfig {
+		forbidden = append(forbidden, key)
+	}
+
+	sort.Strings(forbidden)
+
+	for _, key := range forbidden {
+		v, has := topic.ConfigEntries[key]
+		if !has {
+			continue
+		}
+
+		// A empty forbidden value forbids the property regardless of its value
+		expected := rule.ForbiddenConfig[key]
+		if len(expected) > 0 && !strings.EqualFold(value(v), expected) {
+			continue
+		}
+
+		messages = append(messages, fmt.Sprintf(PolicyForbiddenConfig, key, value(v)))
+	}
+
+	return messages
+}
+
+// Enforce evaluates the policy against the given topics. Warnings are logged and
+// all violations with the error severity are returned, nil is returned if none are found.
+func (policy *Policy) Enforce(topics map[string]Topic) PolicyViolations {
+	errs := PolicyViolations{}
+
+	for _, violation := range policy.Evaluate(topics) {
+		if violation.Severity == SeverityWarn {
+			log.Printf(PolicyViolationWarning, violation.Error())
+			continue
+		}
+
+		errs = append(errs, violation)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
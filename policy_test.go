@@ -0,0 +1,212 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		severity  string
+		forbidden map[string]string
+		err       bool
+	}{
+		{
+			name:     "default severity",
+			source:   "rules:\n  - name: replication\n    min_replication: 3\n",
+			severity: SeverityError,
+		},
+		{
+			name:     "warn severity",
+			source:   "rules:\n  - name: replication\n    severity: warn\n    min_replication: 3\n",
+			severity: SeverityWarn,
+		},
+		{
+			name:      "normalized forbidden values",
+			source:    "rules:\n  - name: retention\n    forbidden_config:\n      retention.ms: 7d\n      cleanup.policy: compact\n",
+			severity:  SeverityError,
+			forbidden: map[string]string{"retention.ms": "604800000", "cleanup.policy": "compact"},
+		},
+		{
+			name:   "unknown severity",
+			source: "rules:\n  - name: replication\n    severity: fatal\n",
+			err:    true,
+		},
+		{
+			name:   "invalid name pattern",
+			source: "rules:\n  - name: naming\n    name_pattern: \"[\"\n",
+			err:    true,
+		},
+		{
+			name:   "overflowing forbidden value",
+			source: "rules:\n  - name: retention\n    forbidden_config:\n      retention.ms: 99999999999w\n",
+			err:    true,
+		},
+		{
+			name:   "unknown field",
+			source: "rules:\n  - name: replication\n    min_replicas: 3\n",
+			err:    true,
+		},
+	}
+
+	directory, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(directory)
+
+	for index, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(directory, string(rune('a'+index))+".yaml")
+			err := ioutil.WriteFile(path, []byte(test.source), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			policy, err := LoadPolicy(path)
+			if test.err {
+				if err == nil {
+					t.Error("expected a error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rule := policy.Rules[0]
+			if rule.Severity != test.severity {
+				t.Errorf("unexpected severity %q, expected %q", rule.Severity, test.severity)
+			}
+
+			if test.forbidden != nil && !reflect.DeepEqual(rule.ForbiddenConfig, test.forbidden) {
+				t.Errorf("unexpected forbidden values %v, expected %v", rule.ForbiddenConfig, test.forbidden)
+			}
+		})
+	}
+}
+
+func TestPolicyRuleCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  PolicyRule
+		topic Topic
+		want  []string
+	}{
+		{
+			name:  "min replication",
+			rule:  PolicyRule{MinReplication: 3},
+			topic: Topic{Name: "orders", ReplicationFactor: 2},
+			want:  []string{"the replication factor 2 is lower than the minimum of 3"},
+		},
+		{
+			name:  "min replication satisfied",
+			rule:  PolicyRule{MinReplication: 3},
+			topic: Topic{Name: "orders", ReplicationFactor: 3},
+			want:  []string{},
+		},
+		{
+			name:  "undefined replication",
+			rule:  PolicyRule{MinReplication: 3},
+			topic: Topic{Name: "orders"},
+			want:  []string{"the replication factor is not defined, a minimum of 3 is required"},
+		},
+		{
+			name:  "max partitions",
+			rule:  PolicyRule{MaxPartitions: 12},
+			topic: Topic{Name: "orders", NumPartitions: 24},
+			want:  []string{"the number of partitions 24 exceeds the maximum of 12"},
+		},
+		{
+			name:  "max partitions satisfied",
+			rule:  PolicyRule{MaxPartitions: 12},
+			topic: Topic{Name: "orders", NumPartitions: 12},
+			want:  []string{},
+		},
+		{
+			name:  "name pattern",
+			rule:  PolicyRule{NamePattern: `^\w+\.\w+\.v\d+$`, pattern: regexp.MustCompile(`^\w+\.\w+\.v\d+$`)},
+			topic: Topic{Name: "orders"},
+			want:  []string{`the topic name does not match the pattern ^\w+\.\w+\.v\d+$`},
+		},
+		{
+			name:  "required config",
+			rule:  PolicyRule{RequiredConfig: []string{"retention.ms", "cleanup.policy"}},
+			topic: Topic{Name: "orders", ConfigEntries: map[string]*string{"retention.ms": ptr("1000")}},
+			want:  []string{"the configuration property cleanup.policy is required"},
+		},
+		{
+			name:  "forbidden value",
+			rule:  PolicyRule{ForbiddenConfig: map[string]string{"unclean.leader.election.enable": "true"}},
+			topic: Topic{Name: "orders", ConfigEntries: map[string]*string{"unclean.leader.election.enable": ptr("TRUE")}},
+			want:  []string{`the configuration property unclean.leader.election.enable is not allowed to be set to "TRUE"`},
+		},
+		{
+			name:  "forbidden value not set",
+			rule:  PolicyRule{ForbiddenConfig: map[string]string{"unclean.leader.election.enable": "true"}},
+			topic: Topic{Name: "orders", ConfigEntries: map[string]*string{"unclean.leader.election.enable": ptr("false")}},
+			want:  []string{},
+		},
+		{
+			name:  "forbidden property",
+			rule:  PolicyRule{ForbiddenConfig: map[string]string{"preallocate": ""}},
+			topic: Topic{Name: "orders", ConfigEntries: map[string]*string{"preallocate": ptr("false")}},
+			want:  []string{`the configuration property preallocate is not allowed to be set to "false"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.rule.Check(test.topic)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected messages %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := &Policy{
+		Rules: []PolicyRule{
+			{Name: "replication", Severity: SeverityError, MinReplication: 3, Exempt: []string{"tmp-*"}},
+			{Name: "partitions", Severity: SeverityWarn, MaxPartitions: 6},
+		},
+	}
+
+	topics := map[string]Topic{
+		"payments": {Name: "payments", ReplicationFactor: 1, NumPartitions: 12},
+		"orders":   {Name: "orders", ReplicationFactor: 3, NumPartitions: 12},
+		"tmp-test": {Name: "tmp-test", ReplicationFactor: 1, NumPartitions: 1},
+	}
+
+	violations := policy.Evaluate(topics)
+
+	got := make([][2]string, 0, len(violations))
+	for _, violation := range violations {
+		got = append(got, [2]string{violation.Topic, violation.Rule})
+	}
+
+	want := [][2]string{
+		{"orders", "partitions"},
+		{"payments", "replication"},
+		{"payments", "partitions"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected violations %v, expected %v", got, want)
+	}
+
+	errs := policy.Enforce(topics)
+	if len(errs) != 1 || errs[0].Topic != "payments" || errs[0].Rule != "replication" {
+		t.Errorf("unexpected enforced violations %v", errs)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Profile messages
+const (
+	ProfileUnknown     = "unknown profile %q"
+	ProfileOverlay     = "the overlay for the topic %s could not reference a profile, profiles are inherited from the base declaration"
+	ProfileUnknownKey  = "unknown key %q under %s of the profile %s"
+	ProfileNotNumeric  = "the %s value %q of the profile %s is not a valid number"
+	ProfileEmptyConfig = "the configuration property %s of the profile %s has no value"
+)
+
+// ProfileTopicKeys holds all keys that could be defined inside the topic section of a profile
+var ProfileTopicKeys = map[string]bool{
+	EntryKeyTopicPartitionSize:   true,
+	EntryKeyTopicReplicationSize: true,
+}
+
+// Profile represents a reusable class of topics. Entries referencing a profile inherit its
+// partitions, replication and configuration properties, values defined inside the entry win.
+type Profile struct {
+	Topic  map[string]string  `yaml:"topic"`
+	Config map[string]*string `yaml:"config"`
+}
+
+// Validate validates the given profile definition
+func (profile Profile) Validate(name string) error {
+	for _, key := range sortedKeys(profile.Topic) {
+		if !ProfileTopicKeys[key] {
+			return fmt.Errorf(ProfileUnknownKey, key, EntryKeyTopic, name)
+		}
+	}
+
+	for _, size := range TopicSizes {
+		raw, has := profile.Topic[size.Key]
+		if !has {
+			continue
+		}
+
+		_, err := strconv.ParseInt(raw, 0, size.Bits)
+		if err != nil {
+			return fmt.Errorf(ProfileNotNumeric, size.Key, raw, name)
+		}
+	}
+
+	for _, key := range sortedConfigKeys(profile.Config) {
+		if len(value(profile.Config[key])) == 0 {
+			return fmt.Errorf(ProfileEmptyConfig, key, name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateProfiles validates the profile references of the given entries.
+// All found problems are returned, nil is returned when all references are valid.
+func ValidateProfiles(entries []Entry, profiles map[string]Profile) ValidationErrors {
+	errs := ValidationErrors{}
+
+	for _, entry := range entries {
+		if len(entry.Profile) == 0 {
+			continue
+		}
+
+		report := func(message string, args ...interface{}) {
+			errs = append(errs, ValidationError{
+				Path:     entry.Path,
+				Document: entry.Document,
+				Line:     entry.LineOf(EntryKeyProfile),
+				Message:  fmt.Sprintf(message, args...),
+			})
+		}
+
+		if entry.Overlay {
+			report(ProfileOverlay, entry.Topic[EntryKeyTopicName])
+			continue
+		}
+
+		_, has := profiles[entry.Profile]
+		if !has {
+			report(ProfileUnknown, entry.Profile)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// Inherit returns a copy of the entry in which all values of the referenced profile that are not
+// defined inside the entry are inherited. The entry is returned as is when no profile is referenced.
+func (entry Entry) Inherit(profiles map[string]Profile) Entry {
+	profile, has := profiles[entry.Profile]
+	if !has {
+		return entry
+	}
+
+	inherited := entry.copy()
+
+	for key, v := range profile.Topic {
+		if len(inherited.Topic[key]) > 0 {
+			continue
+		}
+
+		inherited.Topic[key] = v
+	}
+
+	for key, v := range profile.Config {
+		_, has := inherited.Config[key]
+		if has {
+			continue
+		}
+
+		inherited.Config[key] = v
+	}
+
+	return inherited
+}
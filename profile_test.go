@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestProfileValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		topic map[string]string
+		err   bool
+	}{
+		{name: "empty"},
+		{name: "sizes", topic: map[string]string{"partitions": "70000", "replication": "3"}},
+		{name: "partitions overflow", topic: map[string]string{"partitions": "3000000000"}, err: true},
+		{name: "replication overflow", topic: map[string]string{"replication": "70000"}, err: true},
+		{name: "not numeric", topic: map[string]string{"replication": "three"}, err: true},
+		{name: "unknown key", topic: map[string]string{"name": "orders"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Profile{Topic: test.topic}.Validate("default")
+			if test.err && err == nil {
+				t.Error("expected a error")
+			}
+
+			if !test.err && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultProjectFiles holds the project files looked up in order when no project file is defined
+var DefaultProjectFiles = []string{".kafkat.yaml", "kafkat.yaml"}
+
+// Project messages
+const (
+	ProjectDecodeFailed  = "unable to decode the project file %s: %s"
+	ProjectUnknownPreset = "unknown ignore preset %q, available presets: %v"
+	ProjectInvalid       = "invalid project file %s: %s"
+)
+
+// Ignore presets
+const (
+	PresetKafkaStreams   = "kafka-streams"
+	PresetConnect        = "connect"
+	PresetSchemaRegistry = "schema-registry"
+	PresetMirrorMaker2   = "mirrormaker2"
+)
+
+// IgnorePresets holds the topic name patterns of internal topics created by common Kafka tools
+var IgnorePresets = map[string][]string{
+	PresetKafkaStreams:   {"*-changelog", "*-repartition", "*-subscription-registration-topic", "*-subscription-response-topic"},
+	PresetConnect:        {"connect-configs", "connect-offsets", "connect-status", "_connect-*", "*-connect-configs", "*-connect-offsets", "*-connect-status"},
+	PresetSchemaRegistry: {"_schemas"},
+	PresetMirrorMaker2:   {"heartbeats", "*.heartbeats", "*.checkpoints.internal", "mm2-offset-syncs.*.internal", "mm2-configs.*.internal", "mm2-offsets.*.internal", "mm2-status.*.internal"},
+}
+
+// Project represents the kafkat project file containing settings shared by all runs
+type Project struct {
+	// Ignore holds glob or /regex/ patterns of unmanaged topics that are never marked for deletion
+	Ignore []string `yaml:"ignore"`
+	// Presets holds the names of the ignore presets that are enabled
+	Presets []string `yaml:"presets"`
+	// ManagedPrefixes holds the topic name prefixes owned by the project, all topics are owned when empty
+	ManagedPrefixes []string `yaml:"managed_prefixes"`
+	// Auth holds the TLS and SASL settings used to connect to the Kafka cluster, cluster profiles override these settings
+	Auth Authentication `yaml:"auth"`
+	// Clusters holds the named cluster connection profiles
+	Clusters map[string]Cluster `yaml:"clusters"`
+	// Profiles holds the named topic profiles entries could inherit from
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// LoadProject reads the project file at the given path.
+// A empty project is returned if the file does not exist and optional is set.
+func LoadProject(path string, optional bool) (*Project, error) {
+	project := &Project{}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && optional {
+		return project, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	dec := yaml.NewDecoder(file)
+	dec.KnownFields(true)
+
+	// A empty project file is valid
+	err = dec.Decode(project)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf(ProjectDecodeFailed, path, err)
+	}
+
+	for name, profile := range project.Profiles {
+		err = profile.Validate(name)
+		if err != nil {
+			return nil, fmt.Errorf(ProjectInvalid, path, err)
+		}
+	}
+
+	return project, nil
+}
+
+// FindProjectFile returns the first of the given project files that exists.
+// The first project file is returned when none of the files exist.
+func FindProjectFile(paths []string) string {
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		if err == nil {
+			return path
+		}
+	}
+
+	return paths[0]
+}
+
+// IgnorePatterns resolves the given patterns and the patterns of the given presets into a single list
+func IgnorePatterns(patterns []string, presets []string) ([]string, error) {
+	result := append([]string{}, patterns...)
+
+	for _, preset := range presets {
+		resolved, has := IgnorePresets[preset]
+		if !has {
+			available := []string{PresetKafkaStreams, PresetConnect, PresetSchemaRegistry, PresetMirrorMaker2}
+			return nil, fmt.Errorf(ProjectUnknownPreset, preset, available)
+		}
+
+		result = append(result, resolved...)
+	}
+
+	err := ValidatePatterns(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
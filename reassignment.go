@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"time"
+)
+
+// Reassignment messages
+const (
+	ReassignmentInsufficientBrokers = "the topic %s requires a replication factor of %d but only %d brokers are available"
+	ReassignmentWritten             = "The partition reassignment for: %v, has been written to %s. Execute it with: kafka-reassign-partitions.sh --execute --reassignment-json-file %s\n"
+	ReassignmentRequired            = "the replication factor of the topic %s changes from %d to %d, Kafka %s does not support partition reassignments through the admin API, define -reassignment-out to write the reassignment"
+	ReassignmentPending             = "Waiting for the reassignment of %d partitions of the topics %v to complete\n"
+	ReassignmentCompleted           = "The partition reassignment for: %v, has been completed\n"
+)
+
+// ReassignmentPollInterval is the interval at which the progress of submitted reassignments is checked
+var ReassignmentPollInterval = 5 * time.Second
+
+// CheckReassignments returns a error when the given plan changes the replication factor of a topic while
+// the Kafka cluster does not accept reassignments and no reassignment file is defined.
+// Replication changes are only validated in validate mode.
+func (migration *Migration) CheckReassignments(plan *Plan) error {
+	if migration.ValidateMode || len(migration.ReassignmentFile) > 0 || migration.client.SupportsReassignments() {
+		return nil
+	}
+
+	for _, topic := range plan.Topics {
+		if topic.ChangesReplication() {
+			return fmt.Errorf(ReassignmentRequired, topic.Name, topic.CurrentReplication, topic.ReplicationFactor, migration.client.KafkaVersion)
+		}
+	}
+
+	return nil
+}
+
+// AwaitReassignments blocks until the Kafka cluster completed the given submitted reassignment.
+// The number of pending partitions is logged every poll interval.
+func (migration *Migration) AwaitReassignments(reassignment *Reassignment) error {
+	partitions := make(map[string][]int32)
+	for _, partition := range reassignment.Partitions {
+		partitions[partition.Topic] = append(partitions[partition.Topic], partition.Partition)
+	}
+
+	topics := reassignment.Topics()
+
+	for {
+		pending := 0
+
+		for _, topic := range topics {
+			count, err := migration.client.PendingReassignments(topic, partitions[topic])
+			if err != nil {
+				return err
+			}
+
+			pending += count
+		}
+
+		if pending == 0 {
+			log.Printf(ReassignmentCompleted, topics)
+			return nil
+		}
+
+		log.Printf(ReassignmentPending, pending, topics)
+		time.Sleep(ReassignmentPollInterval)
+	}
+}
+
+// Reassignment represents a Kafka partition reassignment in the format
+// expected by the kafka-reassign-partitions tool.
+type Reassignment struct {
+	Version    int                     `json:"version"`
+	Partitions []PartitionReassignment `json:"partitions"`
+}
+
+// PartitionReassignment represents the target replicas of a single topic partition
+type PartitionReassignment struct {
+	Topic     string  `json:"topic"`
+	Partition int32   `json:"partition"`
+	Replicas  []int32 `json:"replicas"`
+}
+
+// NewReassignment constructs a new reassignment for all replication factor changes inside the given plan
+func NewReassignment(plan *Plan) *Reassignment {
+	reassignment := &Reassignment{
+		Version:    1,
+		Partitions: []PartitionReassignment{},
+	}
+
+	for _, topic := range plan.Topics {
+		if !topic.ChangesReplication() {
+			continue
+		}
+
+		partitions := make([]int32, 0, len(topic.Assignment))
+		for partition := range topic.Assignment {
+			partitions = append(partitions, partition)
+		}
+
+		sort.Slice(partitions, func(i, j int) bool {
+			return partitions[i] < partitions[j]
+		})
+
+		for _, partition := range partitions {
+			reassignment.Partitions = append(reassignment.Partitions, PartitionReassignment{
+				Topic:     topic.Name,
+				Partition: partition,
+				Replicas:  topic.Assignment[partition],
+			})
+		}
+	}
+
+	return reassignment
+}
+
+// Topics returns the names of the topics included inside the reassignment
+func (reassignment *Reassignment) Topics() []string {
+	topics := []string{}
+	seen := make(map[string]bool)
+
+	for _, partition := range reassignment.Partitions {
+		if seen[partition.Topic] {
+			continue
+		}
+
+		seen[partition.Topic] = true
+		topics = append(topics, partition.Topic)
+	}
+
+	return topics
+}
+
+// Save writes the reassignment as JSON to the given file path
+func (reassignment *Reassignment) Save(path string) error {
+	bb, err := json.MarshalIndent(reassignment, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, bb, 0644)
+}
+
+// AssignReplicas constructs a replica assignment for the given number of partitions and replication factor.
+// Existing replicas are preserved as much as possible, the preferred leader is always kept in place when
+// the replication factor is increased. Additional replicas are assigned to the least loaded brokers.
+func AssignReplicas(name string, current map[int32][]int32, partitions int32, replication int16, brokers []int32) (map[int32][]int32, error) {
+	if int(replication) > len(brokers) {
+		return nil, fmt.Errorf(ReassignmentInsufficientBrokers, name, replication, len(brokers))
+	}
+
+	available := make(map[int32]bool, len(brokers))
+	load := make(map[int32]int, len(brokers))
+
+	for _, broker := range brokers {
+		available[broker] = true
+		load[broker] = 0
+	}
+
+	assignment := make(map[int32][]int32, partitions)
+
+	for partition := int32(0); partition < partitions; partition++ {
+		replicas := []int32{}
+
+		for _, replica := range current[partition] {
+			if !available[replica] || len(replicas) == int(replication) {
+				continue
+			}
+
+			replicas = append(replicas, replica)
+		}
+
+		for len(replicas) < int(replication) {
+			candidates := make([]int32, 0, len(brokers))
+
+		search:
+			for _, broker := range brokers {
+				for _, replica := range replicas {
+					if replica == broker {
+						continue search
+					}
+				}
+
+				candidates = append(candidates, broker)
+			}
+
+			sort.Slice(candidates, func(i, j int) bool {
+				if load[candidates[i]] == load[candidates[j]] {
+					return candidates[i] < candidates[j]
+				}
+
+				return load[candidates[i]] < load[candidates[j]]
+			})
+
+			replicas = append(replicas, candidates[0])
+		}
+
+		for _, replica := range replicas {
+			load[replica]++
+		}
+
+		assignment[partition] = replicas
+	}
+
+	return assignment, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestAssignReplicas(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     map[int32][]int32
+		partitions  int32
+		replication int16
+		brokers     []int32
+		want        map[int32][]int32
+	}{
+		{
+			name:        "increase keeps the preferred leader",
+			current:     map[int32][]int32{0: {2}, 1: {3}},
+			partitions:  2,
+			replication: 2,
+			brokers:     []int32{1, 2, 3},
+			want:        map[int32][]int32{0: {2, 1}, 1: {3, 1}},
+		},
+		{
+			name:        "increase balances the load",
+			current:     map[int32][]int32{0: {1}, 1: {1}, 2: {1}},
+			partitions:  3,
+			replication: 2,
+			brokers:     []int32{1, 2, 3},
+			want:        map[int32][]int32{0: {1, 2}, 1: {1, 3}, 2: {1, 2}},
+		},
+		{
+			name:        "decrease keeps the first replicas",
+			current:     map[int32][]int32{0: {3, 1, 2}},
+			partitions:  1,
+			replication: 2,
+			brokers:     []int32{1, 2, 3},
+			want:        map[int32][]int32{0: {3, 1}},
+		},
+		{
+			name:        "unavailable replicas are replaced",
+			current:     map[int32][]int32{0: {4, 1}},
+			partitions:  1,
+			replication: 2,
+			brokers:     []int32{1, 2, 3},
+			want:        map[int32][]int32{0: {1, 2}},
+		},
+		{
+			name:        "new partitions are assigned",
+			current:     map[int32][]int32{0: {1}},
+			partitions:  2,
+			replication: 1,
+			brokers:     []int32{1, 2},
+			want:        map[int32][]int32{0: {1}, 1: {2}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := AssignReplicas("topic", test.current, test.partitions, test.replication, test.brokers)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("unexpected assignment %v, expected %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAssignReplicasInsufficientBrokers(t *testing.T) {
+	_, err := AssignReplicas("topic", nil, 1, 3, []int32{1, 2})
+	if err == nil {
+		t.Error("expected a error when the replication factor exceeds the number of brokers")
+	}
+}
+
+func TestApplyReassignment(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetController(broker.BrokerID()).
+			SetBroker(broker.Addr(), broker.BrokerID()),
+		"AlterPartitionReassignmentsRequest": sarama.NewMockAlterPartitionReassignmentsResponse(t),
+		// The reassignment is pending on the first poll and completed on the second
+		"ListPartitionReassignmentsRequest": sarama.NewMockSequence(
+			sarama.NewMockListPartitionReassignmentsResponse(t),
+			sarama.NewMockWrapper(&sarama.ListPartitionReassignmentsResponse{}),
+		),
+	})
+
+	client, err := NewKafkaAdmin(Cluster{Brokers: []string{broker.Addr()}, KafkaVersion: "2.4.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interval := ReassignmentPollInterval
+	ReassignmentPollInterval = time.Millisecond
+	defer func() {
+		ReassignmentPollInterval = interval
+	}()
+
+	migration := NewMigration()
+	migration.client = client
+
+	plan := &Plan{
+		Topics: []TopicPlan{
+			{
+				Name:               "orders",
+				Action:             ActionUpdate,
+				CurrentReplication: 1,
+				ReplicationFactor:  2,
+				Assignment:         map[int32][]int32{0: {1, 2}, 1: {2, 1}},
+			},
+		},
+	}
+
+	results, err := migration.Apply(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	requests := map[string]int{}
+	for _, request := range broker.History() {
+		requests[reflect.TypeOf(request.Request).Elem().Name()]++
+	}
+
+	if requests["AlterPartitionReassignmentsRequest"] != 1 {
+		t.Errorf("expected a single reassignment request, got %d", requests["AlterPartitionReassignmentsRequest"])
+	}
+
+	if requests["ListPartitionReassignmentsRequest"] != 2 {
+		t.Errorf("expected the reassignment to be polled twice, got %d", requests["ListPartitionReassignmentsRequest"])
+	}
+}
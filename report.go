@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Report output formats
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// Topic results
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+	ResultValid   = "valid"
+	ResultInvalid = "invalid"
+	ResultPlanned = "planned"
+	ResultSkipped = "skipped"
+)
+
+// Report messages
+const (
+	ReportUnknownOutput = "unknown output format %q, expected %s, %s or %s"
+)
+
+// Report represents a structured report of a single run
+type Report struct {
+	Cluster      string        `json:"cluster" yaml:"cluster"`
+	Environment  string        `json:"environment,omitempty" yaml:"environment,omitempty"`
+	ValidateMode bool          `json:"validate" yaml:"validate"`
+	StrictMode   bool          `json:"strict" yaml:"strict"`
+	PlanMode     bool          `json:"plan" yaml:"plan"`
+	StartedAt    time.Time     `json:"started_at" yaml:"started_at"`
+	Duration     string        `json:"duration" yaml:"duration"`
+	Topics       []TopicReport `json:"topics" yaml:"topics"`
+}
+
+// TopicReport represents the planned action and result of a single topic
+type TopicReport struct {
+	Name               string            `json:"name" yaml:"name"`
+	Action             string            `json:"action" yaml:"action"`
+	Result             string            `json:"result" yaml:"result"`
+	Error              string            `json:"error,omitempty" yaml:"error,omitempty"`
+	Source             string            `json:"source,omitempty" yaml:"source,omitempty"`
+	CurrentPartitions  int32             `json:"current_partitions,omitempty" yaml:"current_partitions,omitempty"`
+	NumPartitions      int32             `json:"partitions,omitempty" yaml:"partitions,omitempty"`
+	CurrentReplication int16             `json:"current_replication,omitempty" yaml:"current_replication,omitempty"`
+	ReplicationFactor  int16             `json:"replication,omitempty" yaml:"replication,omitempty"`
+	Before             map[string]string `json:"before,omitempty" yaml:"before,omitempty"`
+	After              map[string]string `json:"after,omitempty" yaml:"after,omitempty"`
+	Duration           string            `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// ValidateOutput returns a error if the given output format is unknown
+func ValidateOutput(output string) error {
+	switch output {
+	case OutputText, OutputJSON, OutputYAML:
+		return nil
+	}
+
+	return fmt.Errorf(ReportUnknownOutput, output, OutputText, OutputJSON, OutputYAML)
+}
+
+// NewReport constructs a report of the given plan and the statuses returned by Apply.
+// Topics without a status are reported as planned, or as skipped when no action was planned.
+func (migration *Migration) NewReport(plan *Plan, statuses []*EntryStatus, started time.Time) *Report {
+	report := &Report{
+		ValidateMode: migration.ValidateMode,
+		StrictMode:   migration.StrictMode,
+		StartedAt:    started,
+		Duration:     time.Since(started).String(),
+		Topics:       make([]TopicReport, 0, len(plan.Topics)),
+	}
+
+	results := make(map[string]*EntryStatus, len(statuses))
+	for _, status := range statuses {
+		results[status.Topic.Name] = status
+	}
+
+	for _, planned := range plan.Topics {
+		topic := TopicReport{
+			Name:               planned.Name,
+			Action:             planned.Action,
+			Result:             ResultPlanned,
+			Source:             planned.Source,
+			CurrentPartitions:  planned.CurrentPartitions,
+			NumPartitions:      planned.NumPartitions,
+			CurrentReplication: planned.CurrentReplication,
+			ReplicationFactor:  planned.ReplicationFactor,
+		}
+
+		topic.Before, topic.After = planned.Configurations()
+
+		if planned.Action == ActionNone {
+			topic.Result = ResultSkipped
+		}
+
+		status, has := results[planned.Name]
+		if has {
+			topic.Duration = status.Duration.String()
+			topic.Result = status.Result(migration.ValidateMode)
+
+			if status.Err != nil {
+				topic.Error = status.Err.Error()
+			}
+		}
+
+		report.Topics = append(report.Topics, topic)
+	}
+
+	return report
+}
+
+// Write writes the report in the given output format to the given writer
+func (report *Report) Write(w io.Writer, output string) error {
+	switch output {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+
+		err := enc.Encode(report)
+		if err != nil {
+			return err
+		}
+
+		return enc.Close()
+	}
+
+	return ValidateOutput(output)
+}
+
+// Result returns the result of the status for the given mode
+func (status *EntryStatus) Result(validate bool) string {
+	switch {
+	case validate && status.Err == nil:
+		return ResultValid
+	case validate:
+		return ResultInvalid
+	case status.Err == nil:
+		return ResultSuccess
+	default:
+		return ResultFailed
+	}
+}
+
+// Configurations returns the configuration of the topic before and after the planned action.
+// A nil configuration is returned for a topic that does not exist before or after the action.
+func (plan TopicPlan) Configurations() (map[string]string, map[string]string) {
+	var before, after map[string]string
+
+	if plan.Action != ActionCreate {
+		before = make(map[string]string, len(plan.Config))
+		for key, v := range plan.Config {
+			before[key] = value(v)
+		}
+
+		for _, change := range plan.Changes {
+			if change.Old == nil {
+				delete(before, change.Key)
+				continue
+			}
+
+			before[change.Key] = *change.Old
+		}
+	}
+
+	if plan.Action != ActionDelete {
+		after = make(map[string]string, len(plan.Config))
+		for key, v := range plan.Config {
+			after[key] = value(v)
+		}
+	}
+
+	return before, after
+}
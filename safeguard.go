@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Safeguard messages
+const (
+	SafeguardMaxDeletions   = "the plan deletes %d topics which exceeds the maximum of %d deletions, aborting"
+	SafeguardProtected      = "the topic %s is protected and could not be deleted"
+	SafeguardConsumerGroups = "the topic %s could not be deleted, it is consumed by the active consumer groups: %s"
+	SafeguardRecentWrites   = "the topic %s could not be deleted, it received writes within the last %s"
+	SafeguardAborted        = "the deletions have not been approved, aborting"
+	SafeguardNoTerminal     = "the deletions could not be confirmed since no interactive terminal is available, use -auto-approve to skip the confirmation"
+	SafeguardConfirm        = "The plan deletes %d topics: %s\nOnly 'yes' will be accepted to approve: "
+)
+
+// ErrDeletionsNotApproved is returned when the planned deletions are not approved
+var ErrDeletionsNotApproved = errors.New(SafeguardAborted)
+
+// Safeguards represents the safeguards protecting topics from being deleted in strict mode
+type Safeguards struct {
+	// MaxDeletions is the maximum number of deletions allowed inside a single plan, a negative value disables the limit
+	MaxDeletions int
+	// Protected holds the topic name patterns that could never be deleted
+	Protected []string
+	// RecentWrites is the window in which topics that received writes could not be deleted, zero disables the check
+	RecentWrites time.Duration
+	// AutoApprove skips the interactive confirmation of deletions
+	AutoApprove bool
+}
+
+// Guard checks the planned deletions against the configured safeguards.
+// A error is returned describing all deletions that are refused.
+func (migration *Migration) Guard(plan *Plan) error {
+	safeguards := migration.Safeguards
+	deletions := plan.Deletions()
+
+	if len(deletions) == 0 {
+		return nil
+	}
+
+	if safeguards.MaxDeletions >= 0 && len(deletions) > safeguards.MaxDeletions {
+		return fmt.Errorf(SafeguardMaxDeletions, len(deletions), safeguards.MaxDeletions)
+	}
+
+	problems := []string{}
+
+	for _, name := range deletions {
+		if MatchPatterns(safeguards.Protected, name) {
+			problems = append(problems, fmt.Sprintf(SafeguardProtected, name))
+		}
+	}
+
+	groups, err := migration.client.ActiveConsumerGroups(deletions)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range deletions {
+		if len(groups[name]) == 0 {
+			continue
+		}
+
+		problems = append(problems, fmt.Sprintf(SafeguardConsumerGroups, name, strings.Join(groups[name], ", ")))
+	}
+
+	if safeguards.RecentWrites > 0 {
+		since := time.Now().Add(-safeguards.RecentWrites)
+
+		for _, name := range deletions {
+			topic, has := migration.Topics[name]
+			if !has {
+				continue
+			}
+
+			written, err := migration.client.RecentlyWritten(topic, since)
+			if err != nil {
+				return err
+			}
+
+			if written {
+				problems = append(problems, fmt.Sprintf(SafeguardRecentWrites, name, safeguards.RecentWrites))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// Confirm asks for a interactive confirmation of the planned deletions.
+// The confirmation is skipped when auto approve is enabled or when no deletions are planned.
+func (migration *Migration) Confirm(plan *Plan, in *os.File, out io.Writer) error {
+	deletions := plan.Deletions()
+	if len(deletions) == 0 || migration.Safeguards.AutoApprove {
+		return nil
+	}
+
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	if stat.Mode()&os.ModeCharDevice == 0 {
+		return errors.New(SafeguardNoTerminal)
+	}
+
+	fmt.Fprintf(out, SafeguardConfirm, len(deletions), strings.Join(deletions, ", "))
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if strings.TrimSpace(answer) != "yes" {
+		return ErrDeletionsNotApproved
+	}
+
+	return nil
+}
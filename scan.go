@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ScanOptions represents the options used to scan for topic configuration files
+type ScanOptions struct {
+	Filter
+	// StrictExtensions rejects files with a unrecognised extension instead of ignoring them
+	StrictExtensions bool
+	// Environment is the environment for which the entries are resolved
+	Environment string
+	// Variables holds the variables referenced inside topic configuration files through ${var.name}
+	Variables map[string]string
+	// Profiles holds the named topic profiles entries could inherit from
+	Profiles map[string]Profile
+}
+
+// Filter represents the include and exclude glob patterns used to discover topic configuration files.
+// Patterns are matched against the slash separated path relative to the scanned target.
+// Patterns without a slash are also matched against the file or directory name.
+// A double star (**) matches any number of directories.
+type Filter struct {
+	Include []string
+	Exclude []string
+	// Skip holds the absolute paths of files that are never scanned such as the project file
+	Skip []string
+}
+
+// Included returns true if the given relative file path is included by the filter.
+// All files are included when no include patterns are defined.
+func (filter Filter) Included(path string) bool {
+	if len(filter.Include) == 0 {
+		return true
+	}
+
+	return MatchAny(filter.Include, path)
+}
+
+// Excluded returns true if the given relative file or directory path is excluded by the filter
+func (filter Filter) Excluded(path string) bool {
+	return MatchAny(filter.Exclude, path)
+}
+
+// Skipped returns true if the given absolute file path is skipped by the filter
+func (filter Filter) Skipped(path string) bool {
+	for _, skip := range filter.Skip {
+		if skip == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Discover recursively walks the given target paths and returns all files accepted by the given filter.
+// Hidden files and directories are skipped.
+// A target could point to a directory or to a single file. The returned paths are ordered by target
+// and lexically within each target.
+func Discover(targets []string, filter Filter) ([]string, error) {
+	files := []string{}
+	seen := make(map[string]bool)
+
+	for _, target := range targets {
+		err := filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(target, path)
+			if err != nil {
+				return err
+			}
+
+			rel = filepath.ToSlash(rel)
+
+			// Hidden files and directories such as the project file or version control
+			// directories are never scanned unless they are explicitly targeted
+			hidden := path != target && strings.HasPrefix(info.Name(), ".")
+
+			if info.IsDir() {
+				if path != target && (hidden || filter.Excluded(rel)) {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if hidden {
+				return nil
+			}
+
+			// Single file targets are matched by their file name
+			if rel == "." {
+				rel = info.Name()
+			}
+
+			if filter.Excluded(rel) || !filter.Included(rel) || filter.Skipped(path) {
+				return nil
+			}
+
+			if seen[path] {
+				return nil
+			}
+
+			seen[path] = true
+			files = append(files, path)
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// MatchAny returns true if the given slash separated path matches any of the given glob patterns
+func MatchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchGlob returns true if the given slash separated path matches the given glob pattern.
+// Patterns without a slash are matched against the last path element.
+func MatchGlob(pattern, path string) bool {
+	if !strings.Contains(pattern, "/") {
+		path = path[strings.LastIndex(path, "/")+1:]
+	}
+
+	expr, err := regexp.Compile(globExpression(pattern))
+	if err != nil {
+		return false
+	}
+
+	return expr.MatchString(path)
+}
+
+// globExpression converts the given glob pattern into a anchored regular expression
+func globExpression(pattern string) string {
+	expr := strings.Builder{}
+	expr.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		char := pattern[i]
+
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case char == '*':
+			expr.WriteString("[^/]*")
+		case char == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+
+	expr.WriteString("$")
+	return expr.String()
+}
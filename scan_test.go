@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.yaml", "orders.yaml", true},
+		{"*.yaml", "payments/orders.yaml", true},
+		{"*.yaml", "orders.json", false},
+		{"drafts", "payments/drafts", true},
+		{"payments/*.yaml", "payments/orders.yaml", true},
+		{"payments/*.yaml", "payments/eu/orders.yaml", false},
+		{"**/*.yaml", "orders.yaml", true},
+		{"**/*.yaml", "payments/eu/orders.yaml", true},
+		{"payments/**/*.yaml", "payments/orders.yaml", true},
+		{"payments/**/*.yaml", "payments/eu/west/orders.yaml", true},
+		{"payments/**/*.yaml", "orders/eu/orders.yaml", false},
+		{"payments/**", "payments/eu/orders.yaml", true},
+		{"order?.yaml", "orders.yaml", true},
+		{"order?.yaml", "order.yaml", false},
+		{"orders.yaml", "ordersxyaml", false},
+		{"[a].yaml", "[a].yaml", true},
+	}
+
+	for _, test := range tests {
+		got := MatchGlob(test.pattern, test.path)
+		if got != test.want {
+			t.Errorf("MatchGlob(%q, %q) = %t, expected %t", test.pattern, test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scope messages
+const (
+	ScopeOutside    = "the topic %s (%s) is outside the managed prefixes %v"
+	ScopeOutsideAll = "%d topics are outside the managed prefixes, aborting"
+)
+
+// Manages returns true if the given topic name is inside the scope managed by the migration.
+// All topics are managed when no managed prefixes are defined.
+func (migration *Migration) Manages(name string) bool {
+	if len(migration.ManagedPrefixes) == 0 {
+		return true
+	}
+
+	for _, prefix := range migration.ManagedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckScope checks whether all topic entries are inside the managed scope.
+// A error is returned describing all topic entries outside the managed scope.
+func (migration *Migration) CheckScope() error {
+	problems := []string{}
+
+	for _, name := range sortedTopicNames(migration.TopicEntries) {
+		if migration.Manages(name) {
+			continue
+		}
+
+		topic := migration.TopicEntries[name]
+		problems = append(problems, fmt.Sprintf(ScopeOutside, name, topic.Source, migration.ManagedPrefixes))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	problems = append(problems, fmt.Sprintf(ScopeOutsideAll, len(problems)))
+	return errors.New(strings.Join(problems, "\n"))
+}
+
+// sortedTopicNames returns the names of the given topics in lexical order
+func sortedTopicNames(topics map[string]Topic) []string {
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Template messages
+const (
+	TemplateUnterminated     = "unterminated expression, missing the closing } of ${"
+	TemplateUndefined        = "undefined variable %s"
+	TemplateUndefinedEnv     = "undefined environment variable %s"
+	TemplateNotNumeric       = "the value %q of %s is not a number"
+	TemplateInvalid          = "invalid expression ${%s}"
+	TemplateDivisionByZero   = "division by zero in the expression ${%s}"
+	TemplateVariablesFailed  = "unable to decode the variables file %s: %s"
+	TemplateInvalidVariable  = "invalid variable %q, expected key=value"
+	TemplateVariablesNotFlat = "unexpected value type %T for the variable %s, expected a scalar"
+)
+
+// TemplateVarPrefix is the prefix of references to user defined variables,
+// references without a prefix refer to environment variables.
+const TemplateVarPrefix = "var."
+
+// reference matches expressions consisting of a single variable reference
+var reference = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?)\s*$`)
+
+// Render substitutes all ${...} expressions inside the decoded values of the given entries.
+// Expressions are only rendered inside scalar values, comments and keys are left untouched and
+// a substituted value could never alter the structure of the document it is defined in.
+// All found problems are returned with the line of the key holding the expression.
+func Render(entries []Entry, variables map[string]string) ValidationErrors {
+	errs := ValidationErrors{}
+
+	for index := range entries {
+		entry := &entries[index]
+		render := func(key string, raw string) string {
+			result, err := RenderValue(raw, variables)
+			if err != nil {
+				errs = append(errs, ValidationError{
+					Path:     entry.Path,
+					Document: entry.Document,
+					Line:     entry.LineOf(key),
+					Message:  err.Error(),
+				})
+			}
+
+			return result
+		}
+
+		entry.Environment = render(EntryKeyEnvironment, entry.Environment)
+		entry.Profile = render(EntryKeyProfile, entry.Profile)
+
+		renderSections(EntryKeyTopic, EntryKeyConfig, entry.Topic, entry.Config, render)
+
+		for _, environment := range sortedEnvironments(entry.Environments) {
+			overrides := entry.Environments[environment]
+			prefix := EntryKeyEnvironments + "." + environment + "."
+			renderSections(prefix+EntryKeyTopic, prefix+EntryKeyConfig, overrides.Topic, overrides.Config, render)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// renderSections renders the values of the given topic and config sections in place
+func renderSections(topicKey, configKey string, topic map[string]string, config map[string]*string, render func(key string, raw string) string) {
+	for _, key := range sortedKeys(topic) {
+		topic[key] = render(topicKey+"."+key, topic[key])
+	}
+
+	for _, key := range sortedConfigKeys(config) {
+		if config[key] == nil {
+			continue
+		}
+
+		result := render(configKey+"."+key, *config[key])
+		config[key] = &result
+	}
+}
+
+// RenderValue substitutes all ${...} expressions inside the given value.
+// A expression is a single variable reference or a integer arithmetic expression (+, -, *, /, %)
+// of numbers and variable references. References prefixed with var. are resolved from the given
+// variables, all other references are resolved from the environment. $${ escapes a expression.
+func RenderValue(raw string, variables map[string]string) (string, error) {
+	result := strings.Builder{}
+
+	for offset := 0; offset < len(raw); {
+		start := strings.Index(raw[offset:], "${")
+		if start < 0 {
+			result.WriteString(raw[offset:])
+			break
+		}
+
+		start += offset
+
+		// Escaped expressions are written without the escape character
+		if start > offset && raw[start-1] == '$' {
+			result.WriteString(raw[offset : start-1])
+			result.WriteString("${")
+			offset = start + 2
+			continue
+		}
+
+		result.WriteString(raw[offset:start])
+
+		end := strings.IndexByte(raw[start:], '}')
+		if end < 0 {
+			return raw, errors.New(TemplateUnterminated)
+		}
+
+		end += start
+
+		v, err := Evaluate(raw[start+2:end], variables)
+		if err != nil {
+			return raw, err
+		}
+
+		result.WriteString(v)
+		offset = end + 1
+	}
+
+	return result.String(), nil
+}
+
+// Evaluate evaluates the given expression. A single variable reference is substituted as is,
+// all other expressions are evaluated as integer arithmetic.
+func Evaluate(expression string, variables map[string]string) (string, error) {
+	if match := reference.FindStringSubmatch(expression); match != nil {
+		return resolve(match[1], variables)
+	}
+
+	parser := &expressionParser{
+		expression: expression,
+		tokens:     tokenize(expression),
+		variables:  variables,
+	}
+
+	result, err := parser.sum()
+	if err != nil {
+		return "", err
+	}
+
+	if parser.position != len(parser.tokens) {
+		return "", fmt.Errorf(TemplateInvalid, expression)
+	}
+
+	return strconv.FormatInt(result, 10), nil
+}
+
+// resolve returns the value of the given variable reference
+func resolve(name string, variables map[string]string) (string, error) {
+	if strings.HasPrefix(name, TemplateVarPrefix) {
+		v, has := variables[strings.TrimPrefix(name, TemplateVarPrefix)]
+		if !has {
+			return "", fmt.Errorf(TemplateUndefined, name)
+		}
+
+		return v, nil
+	}
+
+	v, has := os.LookupEnv(name)
+	if !has {
+		return "", fmt.Errorf(TemplateUndefinedEnv, name)
+	}
+
+	return v, nil
+}
+
+// tokenize splits the given arithmetic expression into numbers, references, operators and parentheses
+func tokenize(expression string) []string {
+	tokens := []string{}
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		char := runes[i]
+
+		switch {
+		case unicode.IsSpace(char):
+			i++
+		case unicode.IsDigit(char) || unicode.IsLetter(char) || char == '_' || char == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(char))
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// expressionParser represents a recursive descent parser of integer arithmetic expressions
+type expressionParser struct {
+	expression string
+	tokens     []string
+	position   int
+	variables  map[string]string
+}
+
+// peek returns the current token or a empty string when all tokens are consumed
+func (parser *expressionParser) peek() string {
+	if parser.position >= len(parser.tokens) {
+		return ""
+	}
+
+	return parser.tokens[parser.position]
+}
+
+// sum parses a sequence of terms separated by + or -
+func (parser *expressionParser) sum() (int64, error) {
+	result, err := parser.product()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		operator := parser.peek()
+		if operator != "+" && operator != "-" {
+			return result, nil
+		}
+
+		parser.position++
+
+		operand, err := parser.product()
+		if err != nil {
+			return 0, err
+		}
+
+		if operator == "+" {
+			result += operand
+			continue
+		}
+
+		result -= operand
+	}
+}
+
+// product parses a sequence of factors separated by *, / or %
+func (parser *expressionParser) product() (int64, error) {
+	result, err := parser.factor()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		operator := parser.peek()
+		if operator != "*" && operator != "/" && operator != "%" {
+			return result, nil
+		}
+
+		parser.position++
+
+		operand, err := parser.factor()
+		if err != nil {
+			return 0, err
+		}
+
+		switch operator {
+		case "*":
+			result *= operand
+		case "/", "%":
+			if operand == 0 {
+				return 0, fmt.Errorf(TemplateDivisionByZero, parser.expression)
+			}
+
+			if operator == "/" {
+				result /= operand
+				continue
+			}
+
+			result %= operand
+		}
+	}
+}
+
+// factor parses a number, variable reference, negation or parenthesised expression
+func (parser *expressionParser) factor() (int64, error) {
+	token := parser.peek()
+	parser.position++
+
+	switch {
+	case token == "-":
+		result, err := parser.factor()
+		return -result, err
+	case token == "(":
+		result, err := parser.sum()
+		if err != nil {
+			return 0, err
+		}
+
+		if parser.peek() != ")" {
+			return 0, fmt.Errorf(TemplateInvalid, parser.expression)
+		}
+
+		parser.position++
+		return result, nil
+	case reference.MatchString(token):
+		v, err := resolve(token, parser.variables)
+		if err != nil {
+			return 0, err
+		}
+
+		result, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf(TemplateNotNumeric, v, token)
+		}
+
+		return result, nil
+	}
+
+	result, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(TemplateInvalid, parser.expression)
+	}
+
+	return result, nil
+}
+
+// LoadVariables reads the variables defined inside the given YAML file.
+// The file should contain a single mapping of scalar values.
+func LoadVariables(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	document := map[string]interface{}{}
+	// A empty variables file is valid
+	err = yaml.NewDecoder(file).Decode(&document)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf(TemplateVariablesFailed, path, err)
+	}
+
+	variables := make(map[string]string, len(document))
+	for key, v := range document {
+		result, err := scalar(key, v)
+		if err != nil {
+			return nil, fmt.Errorf(TemplateVariablesNotFlat, v, key)
+		}
+
+		variables[key] = value(result)
+	}
+
+	return variables, nil
+}
+
+// VariablesFlag represents a key=value flag that could be defined multiple times
+type VariablesFlag map[string]string
+
+func (variables VariablesFlag) String() string {
+	pairs := make([]string, 0, len(variables))
+	for _, key := range sortedKeys(variables) {
+		pairs = append(pairs, key+"="+variables[key])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Set parses the given key=value pair and defines the variable
+func (variables VariablesFlag) Set(pair string) error {
+	index := strings.Index(pair, "=")
+	if index <= 0 {
+		return fmt.Errorf(TemplateInvalidVariable, pair)
+	}
+
+	variables[pair[:index]] = pair[index+1:]
+	return nil
+}
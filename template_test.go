@@ -0,0 +1,148 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	variables := map[string]string{
+		"days":  "7",
+		"stage": "dev",
+		"zero":  "0",
+	}
+
+	tests := []struct {
+		expression string
+		want       string
+		err        bool
+	}{
+		{expression: "var.stage", want: "dev"},
+		{expression: " var.days ", want: "7"},
+		{expression: "1 + 2", want: "3"},
+		{expression: "10 - 4 - 3", want: "3"},
+		{expression: "2 + 3 * 4", want: "14"},
+		{expression: "(2 + 3) * 4", want: "20"},
+		{expression: "20 / 3", want: "6"},
+		{expression: "20 % 3", want: "2"},
+		{expression: "-4 + 10", want: "6"},
+		{expression: "var.days * 24 * 60 * 60 * 1000", want: "604800000"},
+		{expression: "1 / var.zero", err: true},
+		{expression: "var.stage * 2", err: true},
+		{expression: "var.missing", err: true},
+		{expression: "(1 + 2", err: true},
+		{expression: "1 +", err: true},
+		{expression: "1 2", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			got, err := Evaluate(test.expression, variables)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected a error, got %q", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != test.want {
+				t.Errorf("unexpected result %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderValue(t *testing.T) {
+	variables := map[string]string{"stage": "dev", "days": "2"}
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+		err  bool
+	}{
+		{name: "without expressions", raw: "orders", want: "orders"},
+		{name: "reference", raw: "orders-${var.stage}", want: "orders-dev"},
+		{name: "multiple expressions", raw: "${var.stage}-${var.days * 2}", want: "dev-4"},
+		{name: "escaped", raw: "$${var.stage}", want: "${var.stage}"},
+		{name: "escaped and rendered", raw: "$${var.stage}-${var.stage}", want: "${var.stage}-dev"},
+		{name: "unterminated", raw: "orders-${var.stage", err: true},
+		{name: "undefined", raw: "orders-${var.missing}", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := RenderValue(test.raw, variables)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected a error, got %q", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != test.want {
+				t.Errorf("unexpected result %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	source := "# ${UNDEFINED_IN_COMMENT}\ntopic:\n  name: orders-${var.stage}\n  partitions: ${var.partitions}\nconfig:\n  retention.ms: ${var.retention}\n"
+	variables := map[string]string{
+		"stage":      "dev",
+		"partitions": "6",
+		"retention":  "1000\nmin.insync.replicas: 3",
+	}
+
+	entries, err := DecodeYAML(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := Render(entries, variables)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	entry := entries[0]
+	if entry.Topic[EntryKeyTopicName] != "orders-dev" {
+		t.Errorf("unexpected name %q", entry.Topic[EntryKeyTopicName])
+	}
+
+	if entry.Topic[EntryKeyTopicPartitionSize] != "6" {
+		t.Errorf("unexpected partitions %q", entry.Topic[EntryKeyTopicPartitionSize])
+	}
+
+	if value(entry.Config["retention.ms"]) != variables["retention"] {
+		t.Errorf("unexpected retention %q", value(entry.Config["retention.ms"]))
+	}
+
+	if _, has := entry.Config[ConfigMinInsyncReplicas]; has {
+		t.Error("the substituted value should not define new keys")
+	}
+
+	entries, err = DecodeYAML(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs = Render(entries, map[string]string{})
+	if len(errs) != 3 {
+		t.Fatalf("unexpected problems %v, expected 3", errs)
+	}
+
+	if errs[0].Line != 3 {
+		t.Errorf("unexpected line %d, expected 3", errs[0].Line)
+	}
+}
@@ -6,5 +6,10 @@ type Topic struct {
 	ConfigEntries     map[string]*string
 	ReplicationFactor int16
 	NumPartitions     int32
+	ReplicaAssignment map[int32][]int32
 	Delete            bool
+	Source            string
+	// Path and Line refer to the base declaration of the topic, empty if the topic is not declared
+	Path string
+	Line int
 }
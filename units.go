@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Configuration property units
+const (
+	UnitMilliseconds = "ms"
+	UnitBytes        = "bytes"
+)
+
+// Unit messages
+const (
+	UnitOverflow = "the value %q of %s exceeds the maximum of %d %s"
+)
+
+// DurationUnits holds the supported duration units in milliseconds ordered from large to small
+var DurationUnits = []struct {
+	Name  string
+	Value int64
+}{
+	{"w", 7 * 24 * 60 * 60 * 1000},
+	{"d", 24 * 60 * 60 * 1000},
+	{"h", 60 * 60 * 1000},
+	{"m", 60 * 1000},
+	{"s", 1000},
+	{"ms", 1},
+}
+
+// SizeUnits holds the supported size units in bytes ordered by preference when humanizing
+var SizeUnits = []struct {
+	Name  string
+	Value int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"MB", 1000 * 1000},
+	{"KB", 1000},
+	{"B", 1},
+}
+
+// quantity matches a single number followed by a unit
+var quantity = regexp.MustCompile(`(\d+)\s*([A-Za-z]+)`)
+
+// Normalize converts the given human readable value of the given configuration property into the
+// integer expected by Kafka. Durations (7d, 12h, 1h30m) are accepted for millisecond properties and
+// sizes (1GiB, 500MB) for byte properties. The value is returned as is when the property has no unit
+// or when the value could not be converted. A error is returned when the converted value overflows.
+func Normalize(key, raw string) (string, error) {
+	definition, has := Catalogue[key]
+	if !has || len(definition.Unit) == 0 {
+		return raw, nil
+	}
+
+	trimmed := strings.TrimSpace(raw)
+	if _, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+		return raw, nil
+	}
+
+	// The complete value should consist of quantities
+	if len(quantity.ReplaceAllString(strings.Replace(trimmed, " ", "", -1), "")) > 0 {
+		return raw, nil
+	}
+
+	var result int64
+	overflow := fmt.Errorf(UnitOverflow, raw, key, int64(math.MaxInt64), definition.Unit)
+
+	for _, match := range quantity.FindAllStringSubmatch(trimmed, -1) {
+		unit, has := unitValue(definition.Unit, match[2])
+		if !has {
+			return raw, nil
+		}
+
+		number, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || number > math.MaxInt64/unit {
+			return "", overflow
+		}
+
+		if result > math.MaxInt64-number*unit {
+			return "", overflow
+		}
+
+		result += number * unit
+	}
+
+	return strconv.FormatInt(result, 10), nil
+}
+
+// Humanize converts the given integer value of the given configuration property into the largest
+// human readable unit that represents the value exactly. The value is returned as is when the
+// property has no unit or when the value could not be represented in a larger unit.
+func Humanize(key, raw string) string {
+	definition, has := Catalogue[key]
+	if !has || len(definition.Unit) == 0 {
+		return raw
+	}
+
+	number, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || number <= 0 {
+		return raw
+	}
+
+	switch definition.Unit {
+	case UnitMilliseconds:
+		for _, unit := range DurationUnits {
+			// Weeks are accepted but never rendered
+			if unit.Name == "w" {
+				continue
+			}
+
+			if number%unit.Value == 0 {
+				return strconv.FormatInt(number/unit.Value, 10) + unit.Name
+			}
+		}
+	case UnitBytes:
+		for _, unit := range SizeUnits {
+			if unit.Value > 1 && number%unit.Value == 0 {
+				return strconv.FormatInt(number/unit.Value, 10) + unit.Name
+			}
+		}
+	}
+
+	return raw
+}
+
+// Normalize returns a copy of the entry in which all human readable configuration properties are normalized.
+// All values that could not be normalized are returned as ValidationErrors.
+func (entry Entry) Normalize() (Entry, ValidationErrors) {
+	normalized := entry.copy()
+	errs := ValidationErrors{}
+
+	for _, key := range sortedConfigKeys(normalized.Config) {
+		v := normalized.Config[key]
+		if v == nil {
+			continue
+		}
+
+		result, err := Normalize(key, *v)
+		if err != nil {
+			errs = append(errs, ValidationError{
+				Path:     entry.Path,
+				Document: entry.Document,
+				Line:     entry.LineOf(EntryKeyConfig + "." + key),
+				Message:  err.Error(),
+				Topic:    entry.Topic[EntryKeyTopicName],
+			})
+
+			continue
+		}
+
+		normalized.Config[key] = &result
+	}
+
+	if len(errs) == 0 {
+		return normalized, nil
+	}
+
+	return normalized, errs
+}
+
+// unitValue returns the value of the given unit name for the given unit type, unit names are case insensitive
+func unitValue(typ, name string) (int64, bool) {
+	switch typ {
+	case UnitMilliseconds:
+		for _, unit := range DurationUnits {
+			if strings.EqualFold(unit.Name, name) {
+				return unit.Value, true
+			}
+		}
+	case UnitBytes:
+		for _, unit := range SizeUnits {
+			if strings.EqualFold(unit.Name, name) {
+				return unit.Value, true
+			}
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  string
+		want string
+		err  bool
+	}{
+		{key: "retention.ms", raw: "604800000", want: "604800000"},
+		{key: "retention.ms", raw: "7d", want: "604800000"},
+		{key: "retention.ms", raw: "1w", want: "604800000"},
+		{key: "retention.ms", raw: "1h30m", want: "5400000"},
+		{key: "retention.ms", raw: "1h 30m", want: "5400000"},
+		{key: "retention.ms", raw: "-1", want: "-1"},
+		{key: "retention.bytes", raw: "1GiB", want: "1073741824"},
+		{key: "retention.bytes", raw: "500MB", want: "500000000"},
+		{key: "retention.bytes", raw: "1kib", want: "1024"},
+		{key: "retention.bytes", raw: "1d", want: "1d"},
+		{key: "cleanup.policy", raw: "1d", want: "1d"},
+		{key: "unknown.property", raw: "1GiB", want: "1GiB"},
+		{key: "retention.ms", raw: "forever", want: "forever"},
+		{key: "retention.ms", raw: "9223372036854775807ms", want: "9223372036854775807"},
+		{key: "retention.ms", raw: "15250284453w", err: true},
+		{key: "retention.bytes", raw: "8388608TiB", err: true},
+		{key: "retention.ms", raw: "9223372036854775807ms1ms", err: true},
+		{key: "retention.ms", raw: "99999999999999999999ms", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key+"="+test.raw, func(t *testing.T) {
+			got, err := Normalize(test.key, test.raw)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected a error, got %q", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != test.want {
+				t.Errorf("unexpected value %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHumanizeRoundTrip(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  string
+		want string
+	}{
+		{key: "retention.ms", raw: "604800000", want: "7d"},
+		{key: "retention.ms", raw: "5400000", want: "90m"},
+		{key: "retention.ms", raw: "1500", want: "1500ms"},
+		{key: "retention.bytes", raw: "1073741824", want: "1GiB"},
+		{key: "retention.bytes", raw: "500000000", want: "500MB"},
+		{key: "retention.bytes", raw: "1023", want: "1023"},
+		{key: "retention.ms", raw: "-1", want: "-1"},
+		{key: "cleanup.policy", raw: "delete", want: "delete"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key+"="+test.raw, func(t *testing.T) {
+			humanized := Humanize(test.key, test.raw)
+			if humanized != test.want {
+				t.Errorf("unexpected humanized value %q, expected %q", humanized, test.want)
+			}
+
+			normalized, err := Normalize(test.key, humanized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if normalized != test.raw {
+				t.Errorf("unexpected normalized value %q, expected %q", normalized, test.raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validation messages
+const (
+	ValidationUnknownKey      = "unknown key %q"
+	ValidationUnknownTopicKey = "unknown key %q under %s"
+	ValidationMissingTopic    = "the entry does not define a %s section"
+	ValidationMissingName     = "the topic name is not defined"
+	ValidationNotNumeric      = "the %s value %q is not a valid number"
+	ValidationNegative        = "the %s value %q could not be negative"
+	ValidationEmptyConfig     = "the configuration property %s of the topic %s has no value"
+	ValidationDuplicate       = "the topic %s is already declared in %s, use a overlay to extend a existing declaration"
+	ValidationMissingBase     = "the overlay for the topic %s does not have a base declaration"
+	ValidationEnvironmentName = "the topic name could not be overridden inside the %s environment"
+	ValidationEnvironmentKey  = "unknown key %q inside the %s environment"
+)
+
+// EntrySections holds all known root keys of a topic configuration entry
+var EntrySections = map[string]bool{
+	EntryKeyTopic:   true,
+	EntryKeyConfig:  true,
+	EntryKeyOverlay: true,
+
+	EntryKeyEnvironment:  true,
+	EntryKeyEnvironments: true,
+	EntryKeyProfile:      true,
+}
+
+// EnvironmentKeys holds all known keys inside a environment block of a topic configuration entry
+var EnvironmentKeys = map[string]bool{
+	EntryKeyTopic:  true,
+	EntryKeyConfig: true,
+}
+
+// TopicKeys holds all known keys inside the topic section of a topic configuration entry
+var TopicKeys = map[string]bool{
+	EntryKeyTopicName:            true,
+	EntryKeyTopicPartitionSize:   true,
+	EntryKeyTopicReplicationSize: true,
+}
+
+// TopicSizes holds the numeric keys inside the topic section of a topic configuration entry
+// with the bit size of their values
+var TopicSizes = []struct {
+	Key  string
+	Bits int
+}{
+	{EntryKeyTopicPartitionSize, 32},
+	{EntryKeyTopicReplicationSize, 16},
+}
+
+// ValidationError represents a problem inside a topic configuration file
+type ValidationError struct {
+	Path     string
+	Document int
+	Line     int
+	Message  string
+	// Topic is the name of the topic the problem belongs to, empty if unknown
+	Topic string
+}
+
+func (err ValidationError) Error() string {
+	location := err.Path
+	if err.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, err.Line)
+	}
+
+	if err.Document > 0 {
+		location = fmt.Sprintf("%s (document %d)", location, err.Document)
+	}
+
+	return fmt.Sprintf("%s: %s", location, err.Message)
+}
+
+// ValidationErrors represents a collection of problems found inside topic configuration files
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for index, err := range errs {
+		messages[index] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Validate validates the schema of the given topic configuration entries.
+// All found problems are returned, nil is returned when all entries are valid.
+func Validate(entries []Entry) ValidationErrors {
+	errs := ValidationErrors{}
+	declared := make(map[string]Entry, len(entries))
+	overlays := []Entry{}
+
+	for _, entry := range entries {
+		report := func(key string, message string, args ...interface{}) {
+			errs = append(errs, ValidationError{
+				Path:     entry.Path,
+				Document: entry.Document,
+				Line:     entry.LineOf(key),
+				Message:  fmt.Sprintf(message, args...),
+			})
+		}
+
+		for _, key := range entry.Keys() {
+			if !EntrySections[key] {
+				report(key, ValidationUnknownKey, key)
+			}
+		}
+
+		// Ignore empty entries
+		if len(entry.Topic) == 0 && len(entry.Config) == 0 {
+			continue
+		}
+
+		if len(entry.Topic) == 0 {
+			report(EntryKeyConfig, ValidationMissingTopic, EntryKeyTopic)
+			continue
+		}
+
+		for _, key := range sortedKeys(entry.Topic) {
+			if !TopicKeys[key] {
+				report(EntryKeyTopic+"."+key, ValidationUnknownTopicKey, key, EntryKeyTopic)
+			}
+		}
+
+		name := entry.Topic[EntryKeyTopicName]
+		if len(name) == 0 {
+			report(EntryKeyTopic, ValidationMissingName)
+		}
+
+		sizes := func(prefix string, topic map[string]string) {
+			for _, size := range TopicSizes {
+				key := size.Key
+				raw, has := topic[key]
+				if !has || len(raw) == 0 {
+					continue
+				}
+
+				parsed, err := strconv.ParseInt(raw, 0, size.Bits)
+				if err != nil {
+					report(prefix+key, ValidationNotNumeric, key, raw)
+					continue
+				}
+
+				if parsed < 0 {
+					report(prefix+key, ValidationNegative, key, raw)
+				}
+			}
+		}
+
+		sizes(EntryKeyTopic+".", entry.Topic)
+
+		for _, key := range sortedConfigKeys(entry.Config) {
+			// Overlays are allowed to remove inherited properties
+			if entry.Overlay && entry.Config[key] == nil {
+				continue
+			}
+
+			if len(value(entry.Config[key])) == 0 {
+				report(EntryKeyConfig+"."+key, ValidationEmptyConfig, key, name)
+			}
+		}
+
+		for _, environment := range sortedEnvironments(entry.Environments) {
+			overrides := entry.Environments[environment]
+			key := EntryKeyEnvironments + "." + environment
+
+			for _, environmentKey := range overrides.Keys {
+				if !EnvironmentKeys[environmentKey] {
+					report(key+"."+environmentKey, ValidationEnvironmentKey, environmentKey, environment)
+				}
+			}
+
+			for _, topicKey := range sortedKeys(overrides.Topic) {
+				switch {
+				case topicKey == EntryKeyTopicName:
+					report(key, ValidationEnvironmentName, environment)
+				case !TopicKeys[topicKey]:
+					report(key, ValidationUnknownTopicKey, topicKey, key+"."+EntryKeyTopic)
+				}
+			}
+
+			sizes(key+".", overrides.Topic)
+
+			for _, configKey := range sortedConfigKeys(overrides.Config) {
+				v := overrides.Config[configKey]
+				if v != nil && len(*v) == 0 {
+					report(key, ValidationEmptyConfig, configKey, name)
+				}
+			}
+		}
+
+		if len(name) == 0 {
+			continue
+		}
+
+		if entry.Overlay {
+			overlays = append(overlays, entry)
+			continue
+		}
+
+		previous, has := declared[name]
+		if has {
+			report(EntryKeyTopic+"."+EntryKeyTopicName, ValidationDuplicate, name, previous.Location())
+			continue
+		}
+
+		declared[name] = entry
+	}
+
+	for _, overlay := range overlays {
+		name := overlay.Topic[EntryKeyTopicName]
+		_, has := declared[name]
+		if has {
+			continue
+		}
+
+		errs = append(errs, ValidationError{
+			Path:     overlay.Path,
+			Document: overlay.Document,
+			Line:     overlay.LineOf(EntryKeyOverlay),
+			Message:  fmt.Sprintf(ValidationMissingBase, name),
+		})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// sortedKeys returns the keys of the given map in lexical order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedEnvironments returns the names of the given environments in lexical order
+func sortedEnvironments(environments map[string]EnvironmentEntry) []string {
+	keys := make([]string, 0, len(environments))
+	for key := range environments {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedConfigKeys returns the keys of the given configuration in lexical order
+func sortedConfigKeys(config map[string]*string) []string {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateEnvironmentKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		decoder Decoder
+		source  string
+		want    []string
+	}{
+		{
+			name:    "yaml known keys",
+			decoder: DecodeYAML,
+			source:  "topic:\n  name: orders\nenvironments:\n  prod:\n    topic:\n      partitions: 6\n    config:\n      retention.ms: 1000\n",
+			want:    []string{},
+		},
+		{
+			name:    "yaml unknown key",
+			decoder: DecodeYAML,
+			source:  "topic:\n  name: orders\nenvironments:\n  prod:\n    configs:\n      retention.ms: 1000\n",
+			want:    []string{`test.yaml:5 (document 1): unknown key "configs" inside the prod environment`},
+		},
+		{
+			name:    "json unknown key",
+			decoder: DecodeJSON,
+			source:  `{"topic": {"name": "orders"}, "environments": {"dev": {"configs": {"retention.ms": "1000"}}}}`,
+			want:    []string{`test.yaml:1 (document 1): unknown key "configs" inside the dev environment`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := test.decoder(strings.NewReader(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for index := range entries {
+				entries[index].Path = "test.yaml"
+			}
+
+			errs := Validate(entries)
+			if len(errs) != len(test.want) {
+				t.Fatalf("unexpected problems %v, expected %v", errs, test.want)
+			}
+
+			for index, err := range errs {
+				if err.Error() != test.want[index] {
+					t.Errorf("unexpected problem %q, expected %q", err.Error(), test.want[index])
+				}
+			}
+		})
+	}
+}